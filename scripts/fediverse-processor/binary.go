@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================================
+// Binary Layout
+// ============================================================================
+//
+// The binary format is written for the WebGL data-loader worker, which maps
+// each section straight into a typed array. All values are little-endian.
+//
+//	offset  size  field
+//	0       4     magic "FEDI"
+//	4       2     format version (BinaryVersion)
+//	6       2     number of sections
+//	8       4     number of instances (n)
+//	12      4     float32 divisor already applied to positions
+//	16      16*s  section table: name (8 bytes, NUL padded), offset, byte length
+//
+// Every section starts on a 4-byte boundary so Float32Array/Uint32Array views
+// can be created without copying. Readers must look sections up by name and
+// ignore names they do not know.
+//
+//	section    type        per instance
+//	position   float32     x, y, z
+//	users      uint32      stats.user_count
+//	hue        float32     color.hsl.h
+//	software   uint16      index into the side file's "software" table
+//	color      uint8       r, g, b
+//	type       uint8       index into the side file's "types" table
+//
+// Strings live in a JSON side file (see BinaryMeta) whose "instances" array is
+// in the same order as the binary records.
+
+// BinaryVersion is bumped whenever the layout changes incompatibly
+const BinaryVersion = 1
+
+// FormatBinary selects the packed binary output (output only)
+const FormatBinary = "binary"
+
+var binaryMagic = [4]byte{'F', 'E', 'D', 'I'}
+
+const (
+	binaryHeaderSize  = 16
+	binarySectionSize = 16
+)
+
+// BinaryMeta is the JSON side file written next to a binary output
+type BinaryMeta struct {
+	Version       int              `json:"version"`
+	Count         int              `json:"count"`
+	PositionScale float64          `json:"position_scale"`
+	Types         []string         `json:"types"`
+	Software      []string         `json:"software"`
+	Instances     []BinaryMetaItem `json:"instances"`
+}
+
+// BinaryMetaItem holds the string fields of one instance
+type BinaryMetaItem struct {
+	Domain      string `json:"domain"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	FirstSeenAt string `json:"first_seen_at,omitempty"`
+	StarType    string `json:"star_type,omitempty"`
+	Temperature int    `json:"temperature,omitempty"`
+	Nebula      string `json:"nebula,omitempty"`
+
+	// Provenance maps field names to the source that supplied them (see merge)
+	Provenance map[string]string `json:"provenance,omitempty"`
+}
+
+// binaryMetaPath returns the side-file path for a binary output path
+// (data/final.bin -> data/final.meta.json)
+func binaryMetaPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".meta.json"
+}
+
+// binarySection is one named typed array
+type binarySection struct {
+	name string
+	data []byte
+}
+
+// stringTable assigns stable indices to distinct strings in first-seen order
+type stringTable struct {
+	index  map[string]int
+	values []string
+}
+
+func (t *stringTable) id(s string) int {
+	if t.index == nil {
+		t.index = make(map[string]int)
+	}
+	if i, ok := t.index[s]; ok {
+		return i
+	}
+	t.index[s] = len(t.values)
+	t.values = append(t.values, s)
+	return len(t.values) - 1
+}
+
+// ============================================================================
+// Encoding
+// ============================================================================
+
+// EncodeBinary builds the binary body and side file for instances. Positions
+// are divided by positionScale (the frontend's load scale) so the worker can
+// use them unmodified.
+func EncodeBinary(instances []Instance, positionScale float64) ([]byte, BinaryMeta, error) {
+	if positionScale <= 0 {
+		return nil, BinaryMeta{}, fmt.Errorf("position scale (%g) must be positive", positionScale)
+	}
+	n := len(instances)
+	if n > math.MaxUint32 {
+		return nil, BinaryMeta{}, fmt.Errorf("too many instances for the binary format (%d)", n)
+	}
+
+	position := make([]byte, n*12)
+	users := make([]byte, n*4)
+	hue := make([]byte, n*4)
+	software := make([]byte, n*2)
+	color := make([]byte, n*3)
+	kinds := make([]byte, n)
+
+	var types, softwares stringTable
+	meta := BinaryMeta{
+		Version:       BinaryVersion,
+		Count:         n,
+		PositionScale: positionScale,
+		Instances:     make([]BinaryMetaItem, n),
+	}
+	le := binary.LittleEndian
+
+	for i := range instances {
+		inst := &instances[i]
+		if inst.Position == nil {
+			return nil, BinaryMeta{}, fmt.Errorf("instance %q has no position (binary output needs positions)", inst.Domain)
+		}
+		le.PutUint32(position[i*12:], math.Float32bits(float32(inst.Position.X/positionScale)))
+		le.PutUint32(position[i*12+4:], math.Float32bits(float32(inst.Position.Y/positionScale)))
+		le.PutUint32(position[i*12+8:], math.Float32bits(float32(inst.Position.Z/positionScale)))
+
+		if inst.Stats != nil && inst.Stats.UserCount > 0 {
+			le.PutUint32(users[i*4:], uint32(inst.Stats.UserCount))
+		}
+
+		swName := ""
+		if inst.Software != nil {
+			swName = inst.Software.Name
+		}
+		sw := softwares.id(swName)
+		if sw > math.MaxUint16 {
+			return nil, BinaryMeta{}, fmt.Errorf("too many distinct software names for the binary format")
+		}
+		le.PutUint16(software[i*2:], uint16(sw))
+
+		kind := types.id(inst.PositionType)
+		if kind > math.MaxUint8 {
+			return nil, BinaryMeta{}, fmt.Errorf("too many distinct position types for the binary format")
+		}
+		kinds[i] = byte(kind)
+
+		item := BinaryMetaItem{
+			Domain:      inst.Domain,
+			Name:        inst.Name,
+			Description: inst.Description,
+			FirstSeenAt: inst.FirstSeenAt,
+			Nebula:      inst.Nebula,
+			Provenance:  inst.Provenance,
+		}
+		if c := inst.Color; c != nil {
+			le.PutUint32(hue[i*4:], math.Float32bits(float32(c.HSL.H)))
+			color[i*3] = clampByte(c.RGB.R)
+			color[i*3+1] = clampByte(c.RGB.G)
+			color[i*3+2] = clampByte(c.RGB.B)
+			item.StarType = c.StarType
+			item.Temperature = c.Temperature
+		}
+		meta.Instances[i] = item
+	}
+	meta.Types = append([]string{}, types.values...)
+	meta.Software = append([]string{}, softwares.values...)
+
+	sections := []binarySection{
+		{"position", position},
+		{"users", users},
+		{"hue", hue},
+		{"software", software},
+		{"color", color},
+		{"type", kinds},
+	}
+	return packBinary(n, positionScale, sections), meta, nil
+}
+
+// packBinary lays out the header, section table and 4-byte aligned sections
+func packBinary(n int, positionScale float64, sections []binarySection) []byte {
+	le := binary.LittleEndian
+	offset := binaryHeaderSize + binarySectionSize*len(sections)
+	offsets := make([]int, len(sections))
+	for i, s := range sections {
+		offset = align4(offset)
+		offsets[i] = offset
+		offset += len(s.data)
+	}
+
+	out := make([]byte, align4(offset))
+	copy(out[0:4], binaryMagic[:])
+	le.PutUint16(out[4:], BinaryVersion)
+	le.PutUint16(out[6:], uint16(len(sections)))
+	le.PutUint32(out[8:], uint32(n))
+	le.PutUint32(out[12:], math.Float32bits(float32(positionScale)))
+
+	for i, s := range sections {
+		entry := out[binaryHeaderSize+i*binarySectionSize:]
+		copy(entry[0:8], s.name)
+		le.PutUint32(entry[8:], uint32(offsets[i]))
+		le.PutUint32(entry[12:], uint32(len(s.data)))
+		copy(out[offsets[i]:], s.data)
+	}
+	return out
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+func clampByte(v int) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+// WriteBinaryInstances writes the binary file to path and its side file to
+// binaryMetaPath(path)
+func WriteBinaryInstances(path string, instances []Instance, positionScale float64) error {
+	if path == "-" {
+		return fmt.Errorf("binary output needs a file path (it writes a side file next to it)")
+	}
+	body, meta, err := EncodeBinary(instances, positionScale)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %q: %w", dir, err)
+	}
+	if os.Getenv("VERBOSE") == "1" {
+		fmt.Fprintf(os.Stderr, "💾 Writing binary file: %s (%d bytes)\n", path, len(body))
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return fmt.Errorf("cannot write output file %q: %w", path, err)
+	}
+
+	metaPath := binaryMetaPath(path)
+	f, err := os.Create(metaPath)
+	if err != nil {
+		return fmt.Errorf("cannot create side file %q: %w", metaPath, err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := json.NewEncoder(w).Encode(meta); err != nil {
+		return fmt.Errorf("cannot write side file %q: %w", metaPath, err)
+	}
+	return w.Flush()
+}
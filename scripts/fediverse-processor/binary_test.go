@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================
+// A. Binary Layout Tests
+// ============================================================
+
+func binaryFixture() []Instance {
+	return []Instance{
+		{
+			Domain: "a.example", Name: "Alpha",
+			Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 1234},
+			Position: &Position{X: 10, Y: -20, Z: 2.5}, PositionType: "planet",
+			Color: &Color{HSL: HSL{H: 210}, RGB: RGB{R: 10, G: 20, B: 300}, Temperature: 9000, StarType: "Blue Giant"},
+		},
+		{
+			Domain: "b.example", Software: &Software{Name: "Misskey"},
+			Position: &Position{X: 5}, PositionType: "dust",
+			Provenance: map[string]string{"stats.user_count": "fedidb"},
+		},
+		{
+			Domain: "c.example", Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 7},
+			Position: &Position{Z: -5}, PositionType: "planet",
+		},
+	}
+}
+
+// readSections parses the header and section table the way the worker does
+func readSections(t *testing.T, data []byte) (count int, scale float32, sections map[string][]byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	if string(data[0:4]) != "FEDI" || le.Uint16(data[4:]) != BinaryVersion {
+		t.Fatalf("Bad header: %q v%d", data[0:4], le.Uint16(data[4:]))
+	}
+	count = int(le.Uint32(data[8:]))
+	scale = math.Float32frombits(le.Uint32(data[12:]))
+	sections = make(map[string][]byte)
+	for i := 0; i < int(le.Uint16(data[6:])); i++ {
+		entry := data[binaryHeaderSize+i*binarySectionSize:]
+		name := string(entry[0:8])
+		for len(name) > 0 && name[len(name)-1] == 0 {
+			name = name[:len(name)-1]
+		}
+		offset, length := le.Uint32(entry[8:]), le.Uint32(entry[12:])
+		if offset%4 != 0 {
+			t.Errorf("Section %s is not 4-byte aligned (offset %d)", name, offset)
+		}
+		sections[name] = data[offset : offset+length]
+	}
+	return count, scale, sections
+}
+
+func TestEncodeBinary_Layout(t *testing.T) {
+	data, meta, err := EncodeBinary(binaryFixture(), 5)
+	if err != nil {
+		t.Fatalf("EncodeBinary failed: %v", err)
+	}
+	count, scale, sections := readSections(t, data)
+	if count != 3 || scale != 5 {
+		t.Errorf("Header count=%d scale=%g, want 3 and 5", count, scale)
+	}
+
+	le := binary.LittleEndian
+	pos := sections["position"]
+	if len(pos) != 36 {
+		t.Fatalf("position section is %d bytes, want 36", len(pos))
+	}
+	if x := math.Float32frombits(le.Uint32(pos[0:])); x != 2 {
+		t.Errorf("First x = %g, want 10/5 = 2", x)
+	}
+	if y := math.Float32frombits(le.Uint32(pos[4:])); y != -4 {
+		t.Errorf("First y = %g, want -20/5 = -4", y)
+	}
+	if users := le.Uint32(sections["users"][0:]); users != 1234 {
+		t.Errorf("users[0] = %d, want 1234", users)
+	}
+	if hue := math.Float32frombits(le.Uint32(sections["hue"][0:])); hue != 210 {
+		t.Errorf("hue[0] = %g, want 210", hue)
+	}
+	if c := sections["color"]; c[0] != 10 || c[1] != 20 || c[2] != 255 {
+		t.Errorf("color[0] = %v, want clamped [10 20 255]", c[0:3])
+	}
+
+	// Indexed strings resolve through the side file tables
+	sw, kinds := sections["software"], sections["type"]
+	if meta.Software[le.Uint16(sw[4:])] != "Mastodon" || meta.Software[le.Uint16(sw[2:])] != "Misskey" {
+		t.Errorf("Software indices do not resolve: %v", meta.Software)
+	}
+	if meta.Types[kinds[0]] != "planet" || meta.Types[kinds[1]] != "dust" || kinds[0] != kinds[2] {
+		t.Errorf("Type indices do not resolve: %v %v", meta.Types, kinds)
+	}
+	if meta.Instances[0].Domain != "a.example" || meta.Instances[0].Temperature != 9000 {
+		t.Errorf("Unexpected side file entry: %+v", meta.Instances[0])
+	}
+	if meta.Instances[1].Provenance["stats.user_count"] != "fedidb" {
+		t.Errorf("Side file should carry provenance, got %+v", meta.Instances[1])
+	}
+}
+
+func TestEncodeBinary_Errors(t *testing.T) {
+	if _, _, err := EncodeBinary([]Instance{{Domain: "no-position.example"}}, 1); err == nil {
+		t.Error("Expected error for an instance without a position")
+	}
+	if _, _, err := EncodeBinary(binaryFixture(), 0); err == nil {
+		t.Error("Expected error for a zero position scale")
+	}
+}
+
+// ============================================================
+// B. Binary File Output Tests
+// ============================================================
+
+func TestWriteBinaryInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fediverse_final.bin")
+	if err := WriteBinaryInstances(path, binaryFixture(), 1); err != nil {
+		t.Fatalf("WriteBinaryInstances failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data)%4 != 0 {
+		t.Fatalf("Binary file missing or unaligned: %d bytes, %v", len(data), err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(filepath.Dir(path), "fediverse_final.meta.json"))
+	if err != nil {
+		t.Fatalf("Side file missing: %v", err)
+	}
+	var meta BinaryMeta
+	if err := json.Unmarshal(raw, &meta); err != nil || meta.Count != 3 || meta.Version != BinaryVersion {
+		t.Errorf("Unexpected side file: %+v, %v", meta, err)
+	}
+
+	if err := WriteBinaryInstances("-", binaryFixture(), 1); err == nil {
+		t.Error("Expected error writing binary output to stdout")
+	}
+	if _, err := ReadInstances(path, ""); err == nil {
+		t.Error("Expected error reading binary input")
+	}
+}
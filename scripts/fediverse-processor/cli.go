@@ -7,20 +7,60 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // CLIOptions holds parsed command-line arguments
 type CLIOptions struct {
 	InputFile     string
 	OutputFile    string
+	InputFormat   string
+	OutputFormat  string
+	Compression   string
+	Precompress   bool
+	BinaryScale   float64
+	TilesDir      string
+	TileSize      int
+	TileDepth     int
 	ColorOnly     bool
 	PositionsOnly bool
 	Verbose       bool
 	JSONOutput    bool
 	ConfigFile    string
+	Preset        string
+	Sets          stringList
+	AsOf          string
+	Layout        string
+	Workers       int
+	Previous      string
 	Help          bool
 }
 
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ", ") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// configLayers returns the config sources selected on the command line
+func (opts CLIOptions) configLayers() ConfigLayers {
+	return ConfigLayers{
+		Preset:   opts.Preset,
+		File:     opts.ConfigFile,
+		Env:      os.Environ(),
+		Sets:     opts.Sets,
+		AsOf:     opts.AsOf,
+		Layout:   opts.Layout,
+		Workers:  opts.Workers,
+		Previous: opts.Previous,
+	}
+}
+
 // ParseCLI parses command-line arguments
 func ParseCLI() CLIOptions {
 	opts := CLIOptions{}
@@ -29,6 +69,22 @@ func ParseCLI() CLIOptions {
 		"Input JSON file (default: data/fediverse_raw.json, use '-' for stdin)")
 	flag.StringVar(&opts.OutputFile, "output", "",
 		"Output JSON file (default: data/fediverse_final.json, use '-' for stdout)")
+	flag.StringVar(&opts.InputFormat, "input-format", "",
+		"Input format: json or ndjson (default: from extension, else sniffed)")
+	flag.StringVar(&opts.OutputFormat, "output-format", "",
+		"Output format: json, ndjson or binary (default: from extension, else json)")
+	flag.Float64Var(&opts.BinaryScale, "binary-scale", 5.0,
+		"Divisor applied to positions in binary output (matches the frontend's DATA_LOAD_SCALE)")
+	flag.StringVar(&opts.Compression, "output-compression", "",
+		"Output compression: none, gzip or zstd (default: from extension, e.g. .json.gz)")
+	flag.BoolVar(&opts.Precompress, "precompress", false,
+		"Also write .gz and .br copies of the output file for static hosting")
+	flag.StringVar(&opts.TilesDir, "tiles", "",
+		"Also write an octree of level-of-detail tiles and tileset.json to this directory")
+	flag.IntVar(&opts.TileSize, "tile-size", DefaultTileOptions.MaxPerTile,
+		"Maximum instances per tile before the rest move to child tiles")
+	flag.IntVar(&opts.TileDepth, "tile-depth", DefaultTileOptions.MaxDepth,
+		"Maximum octree depth (the deepest tiles keep all remaining instances)")
 	flag.BoolVar(&opts.ColorOnly, "colors-only", false,
 		"Only calculate colors, skip position processing")
 	flag.BoolVar(&opts.PositionsOnly, "positions-only", false,
@@ -39,6 +95,18 @@ func ParseCLI() CLIOptions {
 		"Output statistics as JSON instead of human-readable text")
 	flag.StringVar(&opts.ConfigFile, "config", "",
 		"Configuration file (YAML/JSON format)")
+	flag.StringVar(&opts.Preset, "preset", "",
+		"Named config preset layered under -config (see 'presets list')")
+	flag.Var(&opts.Sets, "set",
+		"Override a config key as key=value (repeatable; overrides "+EnvPrefix+"* environment variables)")
+	flag.StringVar(&opts.AsOf, "as-of", "",
+		"Reference date for instance ages, e.g. 2026-01-01 (default: now)")
+	flag.StringVar(&opts.Layout, "layout", "",
+		"Layout that places instances (default: "+DefaultLayout+"; see 'layouts list')")
+	flag.IntVar(&opts.Workers, "workers", 0,
+		"Goroutines used for colors and positions; output is identical for any value (default: 1)")
+	flag.StringVar(&opts.Previous, "previous", "",
+		"Earlier output whose positions unchanged instances keep (stable placement)")
 	flag.BoolVar(&opts.Help, "help", false,
 		"Print help message")
 
@@ -48,9 +116,12 @@ func ParseCLI() CLIOptions {
 
 USAGE:
   fediverse-processor [options]
+  fediverse-processor <subcommand> [args]
 
-OPTIONS:
+SUBCOMMANDS:
 `)
+		printSubcommands()
+		fmt.Fprintf(os.Stderr, "\nOPTIONS:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, `
 EXAMPLES:
@@ -60,6 +131,36 @@ EXAMPLES:
   # Read from stdin, write to stdout
   cat data/raw.json | fediverse-processor -input=- -output=-
 
+  # Read newline-delimited JSON from a pipeline (the dataset is still held in memory)
+  zcat raw.ndjson.gz | fediverse-processor -input=- -input-format ndjson -output final.ndjson
+
+  # Read an archived snapshot and publish precompressed assets
+  fediverse-processor -input archive/raw.json.zst -output data/final.json -precompress
+
+  # Packed typed arrays for the WebGL worker (also writes data/final.meta.json)
+  fediverse-processor -input data/raw.json -output data/final.bin
+
+  # Octree tiles for progressive loading, in the same format as the output
+  fediverse-processor -output data/final.json -tiles data/tiles
+
+  # Special-edition galaxy shape: barred, elliptical or ring
+  fediverse-processor -layout barred -input data/raw.json -output data/final.json
+
+  # Cluster instances by who federates with whom
+  fediverse-processor -layout force -set force.peers=data/peers/ -set force.iterations=300
+
+  # Name nebulae after hosting providers instead of registrable domains
+  fediverse-processor -set dust.nebula_key=provider -input data/raw.json -output data/final.json
+
+  # Keep last run's positions so refreshed data does not reshuffle the galaxy
+  fediverse-processor -previous data/final.json -input data/raw.json -output data/final.json
+
+  # Push apart instances that land closer than 25 units
+  fediverse-processor -set separation.min_distance=25 -input data/raw.json -output data/final.json
+
+  # Spread colors and positions over 8 goroutines (same output as serial)
+  fediverse-processor -workers 8 -input data/raw.json -output data/final.json
+
   # Colors only
   fediverse-processor -colors-only < data/raw.json > data/colors.json
 
@@ -69,6 +170,21 @@ EXAMPLES:
   # Custom configuration
   fediverse-processor -config config.yaml -input data/raw.json
 
+  # Preset with local tweaks on top
+  fediverse-processor -preset compact -config tweaks.yaml
+
+  # Sweep a single parameter without a config file
+  FEDIPROC_TIER_A_SYSTEM_MAX_RADIUS=5000 fediverse-processor -set domain_hash_range=10
+
+  # Reproduce a published snapshot exactly
+  fediverse-processor -as-of 2026-01-01 -input archive/raw.json -output final.json
+
+  # Fetch raw data from FediDB (re-run to resume)
+  fediverse-processor fetch -output data/raw.json -verbose
+
+  # Check a configuration file in CI
+  fediverse-processor validate-config config.yaml
+
 For more information, visit: https://github.com/r0k1s-i/fediverse-with-100k-stars
 `)
 	}
@@ -86,10 +202,21 @@ For more information, visit: https://github.com/r0k1s-i/fediverse-with-100k-star
 	return opts
 }
 
-// ReadInstances reads instances from input source (file or stdin)
-func ReadInstances(inputFile string) ([]Instance, error) {
+// ReadInstances reads instances from input source (file or stdin). format is
+// "json", "ndjson" or "" to infer it from the file extension or content.
+// gzip and zstd input is recognized by its magic bytes and decompressed.
+// The file is decoded record by record, but the returned slice holds the
+// whole dataset: processing is not bounded in memory.
+func ReadInstances(inputFile, format string) ([]Instance, error) {
 	var reader io.Reader
-	var err error
+
+	format, err := formatForPath(inputFile, format)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatBinary {
+		return nil, fmt.Errorf("the binary format is output-only")
+	}
 
 	if inputFile == "-" {
 		// Read from stdin
@@ -111,24 +238,30 @@ func ReadInstances(inputFile string) ([]Instance, error) {
 		}
 	}
 
-	data, err := io.ReadAll(reader)
+	reader, closeReader, err := decompressReader(reader)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read input: %w", err)
+		return nil, err
 	}
+	defer closeReader()
 
-	var instances []Instance
-	if err := json.Unmarshal(data, &instances); err != nil {
-		return nil, fmt.Errorf("cannot parse JSON: %w", err)
-	}
-
-	return instances, nil
+	return DecodeInstances(reader, format)
 }
 
-// WriteInstances writes instances to output destination (file or stdout)
-func WriteInstances(outputFile string, instances []Instance) error {
-	data, err := json.MarshalIndent(instances, "", "  ")
+// WriteInstances writes instances to output destination (file or stdout).
+// format is "json", "ndjson" or "" to infer it from the file extension
+// (defaulting to json); compression is "none", "gzip", "zstd" or "" to infer
+// it from the extension (.gz, .zst).
+func WriteInstances(outputFile, format, compression string, instances []Instance) error {
+	format, err := formatForPath(outputFile, format)
 	if err != nil {
-		return fmt.Errorf("cannot marshal JSON: %w", err)
+		return err
+	}
+	if format == FormatBinary {
+		return fmt.Errorf("binary output is written with WriteBinaryInstances")
+	}
+	compression, err = compressionForPath(outputFile, compression)
+	if err != nil {
+		return err
 	}
 
 	var writer io.Writer
@@ -158,21 +291,49 @@ func WriteInstances(outputFile string, instances []Instance) error {
 		}
 	}
 
-	if _, err := writer.Write(data); err != nil {
+	zw, err := compressWriter(writer, compression)
+	if err != nil {
+		return err
+	}
+	if err := EncodeInstances(zw, format, instances); err != nil {
+		return fmt.Errorf("cannot write output: %w", err)
+	}
+	if err := zw.Close(); err != nil {
 		return fmt.Errorf("cannot write output: %w", err)
 	}
 
 	return nil
 }
 
-// ProcessInstances applies the specified processing phases
-func ProcessInstances(instances []Instance, cfg Config, opts CLIOptions) []Instance {
+// ProcessInstances applies the specified processing phases and reports
+// data-quality problems found along the way
+func ProcessInstances(instances []Instance, cfg Config, opts CLIOptions) ([]Instance, *ProcessReport) {
+	report := &ProcessReport{DuplicatePolicy: cfg.DuplicatePolicy}
+	instances, report.Duplicates = DedupeInstances(instances, cfg.DuplicatePolicy)
+	if opts.Verbose && len(report.Duplicates) > 0 {
+		fmt.Fprintf(os.Stderr, "🔗 Collapsed %d duplicated domains (policy: %s)\n",
+			len(report.Duplicates), cfg.DuplicatePolicy)
+	}
 	normalizeStats(instances)
 
+	// Pin "now" once so fallback dates and ages agree
+	if cfg.AsOf == "" {
+		cfg.AsOf = time.Now().UTC().Format(time.RFC3339)
+	}
+
 	doColors := !opts.PositionsOnly
 	doPositions := !opts.ColorOnly
 
 	if doColors {
+		var issues []TimestampIssue
+		instances, issues = ResolveCreationDates(instances, cfg)
+		report.TimestampFallback = cfg.TimestampFallback
+		report.TimestampIssues = issues
+		if opts.Verbose && len(issues) > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  %d instances have no usable creation date (fallback: %s)\n",
+				len(issues), cfg.TimestampFallback)
+		}
+
 		if opts.Verbose {
 			fmt.Fprintf(os.Stderr, "🎨 Phase 2: Calculating colors...\n")
 		}
@@ -184,7 +345,7 @@ func ProcessInstances(instances []Instance, cfg Config, opts CLIOptions) []Insta
 
 	if doPositions {
 		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "📍 Phase 3: Calculating positions...\n")
+			fmt.Fprintf(os.Stderr, "📍 Phase 3: Calculating positions (layout: %s)...\n", cfg.Layout)
 		}
 		instances = ProcessPositions(instances, cfg)
 		if opts.Verbose {
@@ -192,16 +353,18 @@ func ProcessInstances(instances []Instance, cfg Config, opts CLIOptions) []Insta
 		}
 	}
 
-	return instances
+	return instances, report
 }
 
 // PrintStatisticsJSON outputs statistics as JSON
-func PrintStatisticsJSON(instances []Instance) {
+func PrintStatisticsJSON(instances []Instance, report *ProcessReport) {
 	stats := map[string]interface{}{
 		"total_instances":       len(instances),
 		"software_distribution": buildSoftwareStats(instances),
 		"position_distribution": buildPositionStats(instances),
+		"dust_strategies":       buildDustStats(instances),
 		"color_statistics":      buildColorStats(instances),
+		"data_quality":          buildDataQualityStats(report),
 	}
 
 	data, _ := json.MarshalIndent(stats, "", "  ")
@@ -225,6 +388,35 @@ func buildPositionStats(instances []Instance) map[string]int {
 	return stats
 }
 
+// buildDustStats counts unknown-software instances by dust strategy
+func buildDustStats(instances []Instance) map[string]int {
+	stats := make(map[string]int)
+	for i := range instances {
+		if s := instances[i].DustStrategy; s != "" {
+			stats[s]++
+		}
+	}
+	return stats
+}
+
+func buildDataQualityStats(report *ProcessReport) map[string]interface{} {
+	issues := report.TimestampIssues
+	if issues == nil {
+		issues = []TimestampIssue{}
+	}
+	duplicates := report.Duplicates
+	if duplicates == nil {
+		duplicates = []DuplicateGroup{}
+	}
+	return map[string]interface{}{
+		"unparseable_timestamps": len(report.TimestampIssues),
+		"timestamp_fallback":     report.TimestampFallback,
+		"timestamp_issues":       issues,
+		"duplicate_policy":       report.DuplicatePolicy,
+		"duplicates":             duplicates,
+	}
+}
+
 func normalizeStats(instances []Instance) {
 	for i := range instances {
 		if instances[i].Stats == nil {
@@ -16,7 +16,8 @@ func domainHash(domain string) float64 {
 	return float64(num) / float64(0xFFFFFFFF)
 }
 
-func parseTime(s string) time.Time {
+// parseTimeStrict parses s using the timestamp layouts seen in FediDB data
+func parseTimeStrict(s string) (time.Time, error) {
 	layouts := []string{
 		time.RFC3339,
 		"2006-01-02T15:04:05.000Z",
@@ -25,20 +26,38 @@ func parseTime(s string) time.Time {
 	}
 	for _, layout := range layouts {
 		if t, err := time.Parse(layout, s); err == nil {
-			return t
+			return t, nil
 		}
 	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// parseTime parses s, returning fallback if it is not a recognized timestamp
+func parseTime(s string, fallback time.Time) time.Time {
+	if t, err := parseTimeStrict(s); err == nil {
+		return t
+	}
+	return fallback
+}
+
+// referenceTime returns the configured as-of time, or the current time if unset
+func (cfg Config) referenceTime() time.Time {
+	if t, err := parseTimeStrict(cfg.AsOf); err == nil {
+		return t
+	}
 	return time.Now()
 }
 
-func getAgeDays(createdAt string) float64 {
-	created := parseTime(createdAt)
-	return time.Since(created).Hours() / 24
+func getAgeDays(createdAt string, cfg Config) float64 {
+	asOf := cfg.referenceTime()
+	created := parseTime(createdAt, asOf)
+	return asOf.Sub(created).Hours() / 24
 }
 
 func getMaxAgeDays(cfg Config) float64 {
-	genesis := parseTime(cfg.GenesisDate)
-	return time.Since(genesis).Hours() / 24
+	asOf := cfg.referenceTime()
+	genesis := parseTime(cfg.GenesisDate, asOf)
+	return asOf.Sub(genesis).Hours() / 24
 }
 
 func logNormalize(value, max float64) float64 {
@@ -52,9 +71,10 @@ func logNormalize(value, max float64) float64 {
 }
 
 func getEraOffset(createdAt string, cfg Config) float64 {
-	created := parseTime(createdAt)
-	eraPre2019 := parseTime(cfg.EraPre2019)
-	eraPost2024 := parseTime(cfg.EraPost2024)
+	asOf := cfg.referenceTime()
+	created := parseTime(createdAt, asOf)
+	eraPre2019 := parseTime(cfg.EraPre2019, asOf)
+	eraPost2024 := parseTime(cfg.EraPost2024, asOf)
 
 	if created.Before(eraPre2019) {
 		return cfg.EraPre2019Offset
@@ -118,12 +138,9 @@ func rgbToHex(rgb RGB) string {
 }
 
 func CalculateColor(instance *Instance, cfg Config) *Color {
-	createdAt := instance.FirstSeenAt
-	if instance.CreationTime != nil && instance.CreationTime.CreatedAt != "" {
-		createdAt = instance.CreationTime.CreatedAt
-	}
+	createdAt, _ := instanceCreatedAt(instance)
 
-	ageDays := getAgeDays(createdAt)
+	ageDays := getAgeDays(createdAt, cfg)
 	maxAgeDays := getMaxAgeDays(cfg)
 	// Use linear normalization for age (not logarithmic)
 	// This ensures young instances are truly young on the spectrum
@@ -308,10 +325,15 @@ func calculateStarType(hue float64, userCount int) string {
 }
 
 func ProcessColors(instances []Instance, cfg Config) []Instance {
+	// Pin "now" once so every instance in the batch is aged against the same instant
+	if cfg.AsOf == "" {
+		cfg.AsOf = time.Now().UTC().Format(time.RFC3339)
+	}
+
 	result := make([]Instance, len(instances))
-	for i := range instances {
+	parallelFor(len(instances), cfg.Workers, func(i int) {
 		result[i] = instances[i]
 		result[i].Color = CalculateColor(&instances[i], cfg)
-	}
+	})
 	return result
 }
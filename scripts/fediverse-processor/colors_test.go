@@ -45,9 +45,10 @@ func TestCalculateColor_OldInstance(t *testing.T) {
 
 func TestCalculateColor_MediumAgeInstance(t *testing.T) {
 	cfg := DefaultConfig
+	cfg.AsOf = "2025-06-01T00:00:00Z"
 	instance := Instance{
 		Domain:      "medium.test",
-		FirstSeenAt: time.Now().AddDate(-2, -6, 0).Format(time.RFC3339), // ~2.5 years old
+		FirstSeenAt: "2022-12-01T00:00:00Z", // ~2.5 years old
 		Stats:       &Stats{UserCount: 1000, MonthlyActiveUsers: 500},
 	}
 
@@ -114,7 +115,7 @@ func TestLogNormalize_MidValue(t *testing.T) {
 
 func TestGetAgeDays_FutureDate(t *testing.T) {
 	futureDate := time.Now().AddDate(1, 0, 0).Format(time.RFC3339)
-	age := getAgeDays(futureDate)
+	age := getAgeDays(futureDate, DefaultConfig)
 
 	// Future dates should result in negative age
 	if age > 0 {
@@ -709,3 +710,55 @@ func TestCalculateColor_SpectrumDistribution(t *testing.T) {
 		t.Errorf("Color spectrum should span at least 100°, got %f°", spectrum)
 	}
 }
+
+// ============================================================
+// H. Reference Clock Tests
+// ============================================================
+
+func TestCalculateColor_AsOfIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.AsOf = "2026-01-01T00:00:00Z"
+	instance := Instance{
+		Domain:      "snapshot.test",
+		FirstSeenAt: "2021-03-15T00:00:00Z",
+		Stats:       &Stats{UserCount: 500, MonthlyActiveUsers: 50},
+	}
+
+	first := CalculateColor(&instance, cfg)
+	second := CalculateColor(&instance, cfg)
+	if *first != *second {
+		t.Errorf("Same as-of time should give identical colors: %+v vs %+v", first, second)
+	}
+
+	// 2021-03-15 to 2026-01-01 is 1753 days
+	if first.Debug.AgeDays != 1753 {
+		t.Errorf("AgeDays should be measured from as-of time, got %d", first.Debug.AgeDays)
+	}
+}
+
+func TestCalculateColor_AsOfShiftsAge(t *testing.T) {
+	instance := Instance{
+		Domain:      "aging.test",
+		FirstSeenAt: "2020-01-01T00:00:00Z",
+		Stats:       &Stats{UserCount: 500, MonthlyActiveUsers: 50},
+	}
+
+	early := DefaultConfig
+	early.AsOf = "2021-01-01"
+	late := DefaultConfig
+	late.AsOf = "2026-01-01"
+
+	// Relative to a later reference the same instance is older, so redder
+	if CalculateColor(&instance, late).HSL.H >= CalculateColor(&instance, early).HSL.H {
+		t.Error("Instance should look older (lower hue) against a later as-of time")
+	}
+}
+
+func TestGetMaxAgeDays_UsesAsOf(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.AsOf = "2017-11-23T00:00:00Z"
+
+	if maxAge := getMaxAgeDays(cfg); maxAge != 365 {
+		t.Errorf("Max age should be genesis to as-of (365 days), got %f", maxAge)
+	}
+}
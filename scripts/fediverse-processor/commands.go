@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Subcommand is an auxiliary tool invoked as `fediverse-processor <name> [args]`
+type Subcommand struct {
+	Summary string
+	Run     func(args []string) int
+}
+
+var subcommands map[string]Subcommand
+
+func init() {
+	subcommands = map[string]Subcommand{
+		"crawl": {
+			Summary: "Verify or enrich instances from their NodeInfo documents",
+			Run:     runCrawl,
+		},
+		"fetch": {
+			Summary: "Download instances from the FediDB API (resumable, with record/replay)",
+			Run:     runFetch,
+		},
+		"layouts": {
+			Summary: "List the registered layouts selectable with -layout",
+			Run:     runLayouts,
+		},
+		"merge": {
+			Summary: "Combine instance datasets by domain with per-field conflict rules",
+			Run:     runMerge,
+		},
+		"presets": {
+			Summary: "List named config presets and how they differ from the defaults",
+			Run:     runPresets,
+		},
+		"validate-config": {
+			Summary: "Check configuration files for invalid or inconsistent values",
+			Run:     runValidateConfig,
+		},
+	}
+}
+
+// printSubcommands lists the available subcommands for the usage message
+func printSubcommands() {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, subcommands[name].Summary)
+	}
+}
+
+// runValidateConfig validates each config file given (or the defaults if none),
+// layered with the preset, FEDIPROC_* environment and -set overrides, and exits
+// non-zero if any of them is invalid, for use in CI.
+func runValidateConfig(args []string) int {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE:\n  fediverse-processor validate-config [-preset name] [-set key=value] [config-file ...]\n\n")
+		fmt.Fprintf(os.Stderr, "Validates each file layered over the defaults; with no files, validates the defaults.\n\n")
+		fs.PrintDefaults()
+	}
+	var sets stringList
+	preset := fs.String("preset", "", "Named config preset to layer under each file")
+	fs.Var(&sets, "set", "Override a config key as key=value (repeatable)")
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	failed := 0
+	for _, file := range files {
+		name := file
+		if file == "" {
+			name = "(defaults)"
+			if *preset != "" {
+				name = fmt.Sprintf("(preset %s)", *preset)
+			}
+		}
+
+		cfg, _, err := ResolveConfig(ConfigLayers{
+			Preset: *preset,
+			File:   file,
+			Env:    os.Environ(),
+			Sets:   sets,
+		})
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s: valid\n", name)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
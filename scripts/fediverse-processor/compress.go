@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression codecs for instance files
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var compressions = []string{CompressionNone, CompressionGzip, CompressionZstd}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressionExt maps compressed file extensions to their codec
+var compressionExt = map[string]string{
+	".gz":   CompressionGzip,
+	".zst":  CompressionZstd,
+	".zstd": CompressionZstd,
+}
+
+// splitCompressionExt returns path without a compression extension and the
+// codec that extension implies (CompressionNone if there is none)
+func splitCompressionExt(path string) (string, string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if codec, ok := compressionExt[ext]; ok {
+		return strings.TrimSuffix(path, filepath.Ext(path)), codec
+	}
+	return path, CompressionNone
+}
+
+// compressionForPath returns the explicit codec if set, otherwise the codec
+// implied by the file extension
+func compressionForPath(path, explicit string) (string, error) {
+	if explicit != "" {
+		if !containsString(compressions, explicit) {
+			return "", fmt.Errorf("unknown compression %q (want one of %s)", explicit, strings.Join(compressions, ", "))
+		}
+		return explicit, nil
+	}
+	_, codec := splitCompressionExt(path)
+	return codec, nil
+}
+
+// decompressReader recognizes gzip and zstd streams by their magic bytes and
+// returns a reader yielding the decompressed data. Uncompressed input is
+// passed through. The returned close function releases decoder resources.
+func decompressReader(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read gzip stream: %w", err)
+		}
+		return zr, func() { zr.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read zstd stream: %w", err)
+		}
+		return zr, zr.Close, nil
+	}
+	return br, func() {}, nil
+}
+
+// compressWriter wraps w in an encoder for codec. The returned WriteCloser
+// must be closed to flush the compressed stream; it does not close w.
+func compressWriter(w io.Writer, codec string) (io.WriteCloser, error) {
+	switch codec {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	case CompressionNone, "":
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", codec)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// ============================================================================
+// Precompressed Siblings
+// ============================================================================
+
+// WritePrecompressed writes path.gz and path.br next to path at maximum
+// compression, for static hosts that serve precompressed assets
+func WritePrecompressed(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %w", path, err)
+	}
+
+	siblings := []struct {
+		ext string
+		enc func(io.Writer) io.WriteCloser
+	}{
+		{".gz", func(w io.Writer) io.WriteCloser {
+			zw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+			return zw
+		}},
+		{".br", func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriterLevel(w, brotli.BestCompression)
+		}},
+	}
+
+	for _, s := range siblings {
+		var buf bytes.Buffer
+		zw := s.enc(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("cannot compress %s%s: %w", path, s.ext, err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("cannot compress %s%s: %w", path, s.ext, err)
+		}
+		if err := os.WriteFile(path+s.ext, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("cannot write %s%s: %w", path, s.ext, err)
+		}
+		if os.Getenv("VERBOSE") == "1" {
+			fmt.Fprintf(os.Stderr, "🗜️  Wrote %s%s (%d bytes)\n", path, s.ext, buf.Len())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ============================================================
+// A. Compression Detection Tests
+// ============================================================
+
+func TestCompressionForPath(t *testing.T) {
+	tests := []struct {
+		path, explicit, want string
+	}{
+		{"raw.json", "", CompressionNone},
+		{"raw.json.gz", "", CompressionGzip},
+		{"raw.ndjson.zst", "", CompressionZstd},
+		{"-", "", CompressionNone},
+		{"-", "zstd", CompressionZstd},
+	}
+	for _, tt := range tests {
+		got, err := compressionForPath(tt.path, tt.explicit)
+		if err != nil || got != tt.want {
+			t.Errorf("compressionForPath(%q, %q) = %q, %v; want %q", tt.path, tt.explicit, got, err, tt.want)
+		}
+	}
+	if _, err := compressionForPath("raw.json", "lz4"); err == nil {
+		t.Error("Expected error for unknown compression")
+	}
+
+	// The format is read from beneath the compression extension
+	if format, _ := formatForPath("raw.ndjson.gz", ""); format != FormatNDJSON {
+		t.Errorf("formatForPath(raw.ndjson.gz) = %q, want ndjson", format)
+	}
+}
+
+// ============================================================
+// B. Compressed Round-Trip Tests
+// ============================================================
+
+func TestReadWriteInstances_Compressed(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"out.json.gz", "out.ndjson.zst", "out.json"} {
+		path := filepath.Join(dir, name)
+		if err := WriteInstances(path, "", "", ioFixture()); err != nil {
+			t.Fatalf("%s: WriteInstances failed: %v", name, err)
+		}
+		got, err := ReadInstances(path, "")
+		if err != nil || len(got) != 3 || got[0].Domain != "a.example" {
+			t.Fatalf("%s: ReadInstances = %+v, %v", name, got, err)
+		}
+	}
+
+	// Magic bytes win over a misleading extension (e.g. a renamed file)
+	os.Rename(filepath.Join(dir, "out.json.gz"), filepath.Join(dir, "renamed.json"))
+	got, err := ReadInstances(filepath.Join(dir, "renamed.json"), "")
+	if err != nil || len(got) != 3 {
+		t.Errorf("Expected gzip to be sniffed from content, got %d instances, %v", len(got), err)
+	}
+}
+
+func TestDecompressReader_PassesThroughPlainInput(t *testing.T) {
+	r, closeReader, err := decompressReader(bytes.NewReader([]byte(`[]`)))
+	if err != nil {
+		t.Fatalf("decompressReader failed: %v", err)
+	}
+	defer closeReader()
+	data, _ := io.ReadAll(r)
+	if string(data) != "[]" {
+		t.Errorf("Expected plain input unchanged, got %q", data)
+	}
+}
+
+// ============================================================
+// C. Precompressed Sibling Tests
+// ============================================================
+
+func TestWritePrecompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fediverse_final.json")
+	if err := WriteInstances(path, "", "", ioFixture()); err != nil {
+		t.Fatalf("WriteInstances failed: %v", err)
+	}
+	if err := WritePrecompressed(path); err != nil {
+		t.Fatalf("WritePrecompressed failed: %v", err)
+	}
+	plain, _ := os.ReadFile(path)
+
+	gz, err := ReadInstances(path+".gz", FormatJSON)
+	if err != nil || len(gz) != 3 {
+		t.Errorf(".gz sibling did not decode: %v", err)
+	}
+
+	f, err := os.Open(path + ".br")
+	if err != nil {
+		t.Fatalf(".br sibling missing: %v", err)
+	}
+	defer f.Close()
+	br, _ := io.ReadAll(brotli.NewReader(f))
+	if !bytes.Equal(br, plain) {
+		t.Error(".br sibling does not decompress to the plain file")
+	}
+}
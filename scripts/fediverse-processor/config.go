@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a JSON or YAML configuration file and overlays it on base.
+// Keys omitted from the file keep their value from base, and maps and lists
+// given in the file replace the base value whole; unknown keys are
+// rejected with a "file:line" error so typos don't silently fall back to defaults.
+func LoadConfig(path string, base Config) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, fmt.Errorf("cannot read config file %q: %w", path, err)
+	}
+	cfg, _, err := parseConfig(path, data, base)
+	return cfg, err
+}
+
+// parseConfig decodes config data named by path (used for error messages and
+// format detection) on top of base. It also returns the keys the data sets.
+func parseConfig(path string, data []byte, base Config) (Config, []string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json", ".yaml", ".yml":
+	default:
+		return base, nil, fmt.Errorf("%s: unsupported config format %q (want .json, .yaml or .yml)", path, ext)
+	}
+
+	// JSON is a subset of YAML, so a single parser handles both formats and
+	// gives us line numbers for error reporting.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return base, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		// Empty file: nothing to overlay
+		return base, nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return base, nil, fmt.Errorf("%s:%d: config must be a mapping of keys to values", path, root.Line)
+	}
+	var keys []string
+	if err := checkKnownKeys(path, root, reflect.TypeOf(Config{}), "", &keys); err != nil {
+		return base, nil, err
+	}
+
+	// yaml.v3 decodes into existing maps key by key, so clear the maps the
+	// file sets: like -set, a map in the file replaces the base map whole
+	cfg := base.clone()
+	for _, key := range keys {
+		if field, err := configField(&cfg, key); err == nil && field.Kind() == reflect.Map {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	if err := root.Decode(&cfg); err != nil {
+		return base, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, keys, nil
+}
+
+// checkKnownKeys walks a mapping node and reports the first key that has no
+// matching field in t. Nested structs and lists of structs are checked recursively.
+// Leaf keys (as listed by configKeyPaths) are appended to keys when it is non-nil.
+func checkKnownKeys(path string, node *yaml.Node, t reflect.Type, prefix string, keys *[]string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil // Type mismatches are reported by Decode
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				return fmt.Errorf("%s:%d: unknown config key %q", path, key.Line, prefix+key.Value)
+			}
+			fieldKeys := keys
+			if field.Type.Kind() != reflect.Struct {
+				if keys != nil {
+					*keys = append(*keys, prefix+key.Value)
+				}
+				fieldKeys = nil
+			}
+			if err := checkKnownKeys(path, value, field.Type, prefix+key.Value+".", fieldKeys); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			if err := checkKnownKeys(path, item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(prefix, "."), i), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// yamlFields maps yaml key names to the exported struct fields of t
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// clone returns a copy of c that shares no slices or maps with the original
+func (c Config) clone() Config {
+	out := c
+	out.Supergiants = append([]Supergiant(nil), c.Supergiants...)
+	for i := range out.Supergiants {
+		if p := out.Supergiants[i].Position; p != nil {
+			copied := *p
+			out.Supergiants[i].Position = &copied
+		}
+	}
+	out.Separation.TypeScale = cloneFloatMap(c.Separation.TypeScale)
+	out.Dust.Weights = cloneFloatMap(c.Dust.Weights)
+	return out
+}
+
+func cloneFloatMap(m map[string]float64) map[string]float64 {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]float64, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// PrintConfig writes the effective configuration, one key per line in sorted
+// order, annotated with the source of each value when sources is non-nil
+func PrintConfig(w io.Writer, cfg Config, sources ConfigSources) {
+	fmt.Fprintln(w, "⚙️  Effective configuration:")
+	fields := configFieldMap(cfg)
+	for _, key := range configKeyPaths() {
+		v, _ := json.Marshal(fields[key])
+		if src, ok := sources[key]; ok {
+			fmt.Fprintf(w, "  %-28s %-24s (%s)\n", key, v, src)
+		} else {
+			fmt.Fprintf(w, "  %-28s %s\n", key, v)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Config File Loading Tests
+// ============================================================
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_JSONOverlaysDefaults(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+	"tier_a_system_radius": 20000,
+	"supergiants": [{"domain": "mastodon.social", "software": "Mastodon"}]
+}`)
+
+	cfg, err := LoadConfig(path, DefaultConfig)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.TierASystemRadius != 20000 {
+		t.Errorf("TierASystemRadius = %f, want 20000", cfg.TierASystemRadius)
+	}
+	if len(cfg.Supergiants) != 1 || cfg.Supergiants[0].Domain != "mastodon.social" {
+		t.Errorf("Supergiants = %v, want [mastodon.social]", cfg.Supergiants)
+	}
+	// Omitted keys keep their defaults
+	if cfg.HueYoung != DefaultConfig.HueYoung {
+		t.Errorf("HueYoung = %f, want default %f", cfg.HueYoung, DefaultConfig.HueYoung)
+	}
+	if cfg.GenesisDate != DefaultConfig.GenesisDate {
+		t.Errorf("GenesisDate = %q, want default %q", cfg.GenesisDate, DefaultConfig.GenesisDate)
+	}
+}
+
+func TestLoadConfig_YAMLOverlaysDefaults(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+# Compact galaxy
+tier_a_instance_count: 50
+saturation_min: 35.5
+genesis_date: "2017-01-01T00:00:00Z"
+`)
+
+	cfg, err := LoadConfig(path, DefaultConfig)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.TierAInstanceCount != 50 {
+		t.Errorf("TierAInstanceCount = %d, want 50", cfg.TierAInstanceCount)
+	}
+	if cfg.SaturationMin != 35.5 {
+		t.Errorf("SaturationMin = %f, want 35.5", cfg.SaturationMin)
+	}
+	if cfg.GenesisDate != "2017-01-01T00:00:00Z" {
+		t.Errorf("GenesisDate = %q, want 2017-01-01T00:00:00Z", cfg.GenesisDate)
+	}
+	if cfg.TierBInstanceCount != DefaultConfig.TierBInstanceCount {
+		t.Errorf("TierBInstanceCount = %d, want default %d", cfg.TierBInstanceCount, DefaultConfig.TierBInstanceCount)
+	}
+}
+
+func TestLoadConfig_UnknownKeyReportsLine(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "hue_young: 200\n\ntier_a_radius: 1\n")
+
+	_, err := LoadConfig(path, DefaultConfig)
+	if err == nil {
+		t.Fatal("Expected error for unknown key")
+	}
+	want := path + ":3: unknown config key \"tier_a_radius\""
+	if err.Error() != want {
+		t.Errorf("Error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoadConfig_TypeMismatch(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"tier_a_instance_count": "many"}`)
+
+	if _, err := LoadConfig(path, DefaultConfig); err == nil {
+		t.Error("Expected error for string value in int field")
+	}
+}
+
+func TestLoadConfig_UnsupportedExtension(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", "hue_young = 200\n")
+
+	_, err := LoadConfig(path, DefaultConfig)
+	if err == nil || !strings.Contains(err.Error(), "unsupported config format") {
+		t.Errorf("Expected unsupported format error, got %v", err)
+	}
+}
+
+func TestLoadConfig_DoesNotMutateBase(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "supergiants:\n  - domain: a.example\n    position: {x: 1, y: 2, z: 3}\n")
+
+	base := DefaultConfig.clone()
+	if _, err := LoadConfig(path, base); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if base.Supergiants[0].Domain != "mastodon.social" {
+		t.Errorf("Base config was mutated: %v", base.Supergiants)
+	}
+}
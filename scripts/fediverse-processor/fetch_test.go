@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// Test Fixtures: stand-in FediDB server
+// ============================================================
+
+// fakeFediDB serves three pages of servers keyed by cursor. The first request
+// for page 2 fails with 503 to exercise retries.
+func fakeFediDB(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	var failed int32
+
+	pages := map[string]string{
+		"": `{"data":[
+			{"domain":"mastodon.social","description":"The original","first_seen_at":"2016-11-23T00:00:00.000Z",
+			 "software":{"name":"Mastodon","version":"4.3.0"},"stats":{"user_count":2500000,"monthly_active_users":300000}},
+			{"domain":"misskey.io","software":{"name":"Misskey"},"stats":{"user_count":500000,"monthly_active_users":80000}}
+		],"meta":{"next_cursor":"p2"}}`,
+		"p2": `{"data":[
+			{"domain":"pixelfed.social","software":{"name":"Pixelfed"},"stats":{"user_count":100000,"monthly_active_users":5000}}
+		],"meta":{"next_cursor":"p3"}}`,
+		"p3": `{"data":[
+			{"domain":"tiny.example","first_seen_at":"2024-05-01"}
+		],"meta":{"next_cursor":null}}`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/v1/servers" {
+			http.NotFound(w, r)
+			return
+		}
+		cursor := r.URL.Query().Get("cursor")
+		if cursor == "p2" && atomic.CompareAndSwapInt32(&failed, 0, 1) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, ok := pages[cursor]
+		if !ok {
+			http.Error(w, "bad cursor", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func testClient(baseURL string, transport http.RoundTripper) *FediDBClient {
+	c := NewFediDBClient(baseURL, transport)
+	c.Interval = 0
+	c.Backoff = time.Millisecond
+	c.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return c
+}
+
+// ============================================================
+// A. Fetch and Mapping Tests
+// ============================================================
+
+func TestFetchAll_PagesAndMapsRecords(t *testing.T) {
+	srv, requests := fakeFediDB(t)
+	client := testClient(srv.URL+"/v1", http.DefaultTransport)
+
+	instances, state, err := FetchAll(context.Background(), client, t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("FetchAll failed: %v", err)
+	}
+
+	if !state.Done || state.Pages != 3 {
+		t.Errorf("Expected 3 pages and done, got %+v", state)
+	}
+	if *requests != 4 {
+		t.Errorf("Expected 4 requests (one retry), got %d", *requests)
+	}
+	if len(instances) != 4 {
+		t.Fatalf("Expected 4 instances, got %d", len(instances))
+	}
+
+	ms := instances[0]
+	if ms.Domain != "mastodon.social" || ms.Software.Name != "Mastodon" {
+		t.Errorf("Unexpected first instance: %+v", ms)
+	}
+	if ms.Stats.UserCount != 2500000 || ms.Stats.MonthlyActiveUsers != 300000 {
+		t.Errorf("Stats not mapped: %+v", ms.Stats)
+	}
+	if ms.CreationTime == nil || ms.CreationTime.CreatedAt != "2016-11-23T00:00:00.000Z" || ms.CreationTime.Reliable {
+		t.Errorf("CreationTime not mapped: %+v", ms.CreationTime)
+	}
+
+	tiny := instances[3]
+	if tiny.Software != nil || tiny.Stats != nil {
+		t.Errorf("Missing software/stats should stay nil: %+v", tiny)
+	}
+}
+
+func TestFetchAll_ResumesFromCursor(t *testing.T) {
+	srv, _ := fakeFediDB(t)
+	client := testClient(srv.URL+"/v1", http.DefaultTransport)
+	stateDir := t.TempDir()
+
+	_, state, err := FetchAll(context.Background(), client, stateDir, 1)
+	if err != nil {
+		t.Fatalf("First FetchAll failed: %v", err)
+	}
+	if state.Done || state.NextCursor != "p2" {
+		t.Fatalf("Expected to stop at cursor p2, got %+v", state)
+	}
+
+	instances, state, err := FetchAll(context.Background(), client, stateDir, 0)
+	if err != nil {
+		t.Fatalf("Resumed FetchAll failed: %v", err)
+	}
+	if !state.Done || len(instances) != 4 {
+		t.Errorf("Resume should complete with 4 instances, got %d (%+v)", len(instances), state)
+	}
+}
+
+func TestFetchAll_CompletedFetchStartsOver(t *testing.T) {
+	srv, requests := fakeFediDB(t)
+	client := testClient(srv.URL+"/v1", http.DefaultTransport)
+	stateDir := t.TempDir()
+
+	if _, state, err := FetchAll(context.Background(), client, stateDir, 0); err != nil || !state.Done {
+		t.Fatalf("First FetchAll failed: %v (%+v)", err, state)
+	}
+	first := atomic.LoadInt32(requests)
+
+	instances, state, err := FetchAll(context.Background(), client, stateDir, 0)
+	if err != nil {
+		t.Fatalf("Second FetchAll failed: %v", err)
+	}
+	if got := atomic.LoadInt32(requests) - first; got != 3 {
+		t.Errorf("A completed fetch should be fetched again: %d requests, want 3", got)
+	}
+	// The instance log is replaced, not appended to
+	if !state.Done || state.Pages != 3 || len(instances) != 4 || state.Instances != 4 {
+		t.Errorf("Expected a fresh 3-page fetch of 4 instances, got %d (%+v)", len(instances), state)
+	}
+}
+
+func TestResetFetchState_DiscardsUnfinishedFetch(t *testing.T) {
+	srv, _ := fakeFediDB(t)
+	client := testClient(srv.URL+"/v1", http.DefaultTransport)
+	stateDir := t.TempDir()
+
+	if _, _, err := FetchAll(context.Background(), client, stateDir, 1); err != nil {
+		t.Fatalf("First FetchAll failed: %v", err)
+	}
+	if err := ResetFetchState(stateDir); err != nil {
+		t.Fatalf("ResetFetchState failed: %v", err)
+	}
+	_, state, err := FetchAll(context.Background(), client, stateDir, 1)
+	if err != nil {
+		t.Fatalf("FetchAll after reset failed: %v", err)
+	}
+	if state.Pages != 1 || state.NextCursor != "p2" {
+		t.Errorf("Expected to start over from the first page, got %+v", state)
+	}
+}
+
+func TestFetchAll_CrashBeforeCheckpointLogsPageOnce(t *testing.T) {
+	srv, _ := fakeFediDB(t)
+	client := testClient(srv.URL+"/v1", http.DefaultTransport)
+	stateDir := t.TempDir()
+
+	if _, _, err := FetchAll(context.Background(), client, stateDir, 1); err != nil {
+		t.Fatalf("First FetchAll failed: %v", err)
+	}
+	// Simulate a crash after page 2 reached the log but not the checkpoint
+	log, err := os.OpenFile(filepath.Join(stateDir, fetchInstancesFile), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(log, `{"domain":"pixelfed.social"}`)
+	log.Close()
+
+	instances, state, err := FetchAll(context.Background(), client, stateDir, 0)
+	if err != nil {
+		t.Fatalf("Resumed FetchAll failed: %v", err)
+	}
+	if !state.Done || state.Instances != 4 || len(instances) != 4 {
+		t.Errorf("Expected 4 instances counted once, got %d (%+v)", len(instances), state)
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, fetchInstancesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected the re-fetched page logged once, log has %d records", lines)
+	}
+}
+
+func TestFetchPage_GivesUpAfterRetries(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := testClient(srv.URL, http.DefaultTransport)
+	client.MaxRetries = 2
+	var waits []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	if _, err := client.FetchPage(context.Background(), ""); err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 attempts, got %d", requests)
+	}
+	for _, w := range waits {
+		if w > 0 && w < time.Second {
+			t.Errorf("Retry-After should raise the wait to at least 1s, got %v", w)
+		}
+	}
+}
+
+func TestFetchPage_NoRetryOnClientError(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if _, err := testClient(srv.URL, http.DefaultTransport).FetchPage(context.Background(), ""); err == nil {
+		t.Fatal("Expected error for 403")
+	}
+	if requests != 1 {
+		t.Errorf("4xx should not be retried, got %d requests", requests)
+	}
+}
+
+// ============================================================
+// B. Record / Replay Tests
+// ============================================================
+
+func TestFetchAll_RecordThenReplayOffline(t *testing.T) {
+	srv, _ := fakeFediDB(t)
+	baseURL := srv.URL + "/v1"
+	recordDir := filepath.Join(t.TempDir(), "recordings")
+
+	recorded := testClient(baseURL, &RecordingTransport{Dir: recordDir, Next: http.DefaultTransport})
+	want, _, err := FetchAll(context.Background(), recorded, t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Recording fetch failed: %v", err)
+	}
+
+	// Shut the server down: replay must not need the network
+	srv.Close()
+
+	replayed := testClient(baseURL, &ReplayTransport{Dir: recordDir})
+	got, state, err := FetchAll(context.Background(), replayed, t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Replay fetch failed: %v", err)
+	}
+	if !state.Done || len(got) != len(want) {
+		t.Fatalf("Replay returned %d instances (done=%v), want %d", len(got), state.Done, len(want))
+	}
+	for i := range want {
+		if got[i].Domain != want[i].Domain {
+			t.Errorf("Instance %d: replay %s, recorded %s", i, got[i].Domain, want[i].Domain)
+		}
+	}
+}
+
+func TestReplay_SkipsWaits(t *testing.T) {
+	// A recorded 429 asking for an hour's pause, replayed without waiting
+	dir := t.TempDir()
+	baseURL := "http://fedidb.invalid/v1"
+	client := NewFediDBClient(baseURL, &ReplayTransport{Dir: dir})
+	rec := `{"url":"` + client.pageURL("") + `","status":429,"retry_after":"3600","body":""}`
+	if err := os.WriteFile(recordingPath(dir, client.pageURL("")), []byte(rec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client.MaxRetries = 2
+	start := time.Now()
+	if _, err := client.FetchPage(context.Background(), ""); err == nil {
+		t.Error("Expected the recorded 429 to be returned")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Replay waited %v for the rate limit and backoff", elapsed)
+	}
+}
+
+func TestReplayTransport_MissingRecording(t *testing.T) {
+	client := testClient("http://fedidb.invalid/v1", &ReplayTransport{Dir: t.TempDir()})
+	client.MaxRetries = 0
+
+	if _, err := client.FetchPage(context.Background(), ""); err == nil {
+		t.Error("Expected error for missing recording")
+	}
+}
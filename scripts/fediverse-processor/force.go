@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// Force-Directed Layout
+// ============================================================================
+
+func init() {
+	registerLayout(forceLayout{})
+}
+
+// forceLayout places instances with a Fruchterman-Reingold simulation over the
+// federation peer graph: peers attract, every pair repels (approximated with a
+// Barnes-Hut octree), and a weak gravity keeps unconnected instances nearby.
+type forceLayout struct{}
+
+func (forceLayout) Name() string { return "force" }
+
+func (forceLayout) Description() string {
+	return "Instances that federate with each other cluster together (needs force.peers)"
+}
+
+func (forceLayout) Place(instances []Instance, cfg Config) []Instance {
+	n := len(instances)
+	result := make([]Instance, n)
+	copy(result, instances)
+	if n == 0 {
+		return result
+	}
+
+	sim := newForceSim(result, cfg.Force)
+	sim.run(cfg.Force.Iterations)
+
+	// Scale so the farthest instance sits at the configured radius
+	maxDist := 0.0
+	for i := 0; i < n; i++ {
+		maxDist = math.Max(maxDist, math.Sqrt(sim.px[i]*sim.px[i]+sim.py[i]*sim.py[i]+sim.pz[i]*sim.pz[i]))
+	}
+	scale := 1.0
+	if maxDist > 0 {
+		scale = cfg.Force.Radius / maxDist
+	}
+
+	for i := range result {
+		inst := &result[i]
+		inst.Position = &Position{
+			X: math.Round(sim.px[i]*scale*10) / 10,
+			Y: math.Round(sim.py[i]*scale*10) / 10,
+			Z: math.Round(sim.pz[i]*scale*10) / 10,
+		}
+		if isSuperGiant(inst.Domain, cfg) {
+			inst.PositionType = "supergiant"
+		} else {
+			inst.PositionType = classifyInstanceSize(getInstanceUserCount(inst), cfg)
+		}
+	}
+	return result
+}
+
+// forceSim holds the simulation state in struct-of-arrays form
+type forceSim struct {
+	px, py, pz []float64 // Positions
+	dx, dy, dz []float64 // Displacement accumulated in the current step
+	edges      [][2]int32
+	theta      float64
+	gravity    float64
+	tree       bhTree
+}
+
+// newForceSim seeds positions from the domain hash (so they do not depend on
+// input order) and builds the federation edge list
+func newForceSim(instances []Instance, fc ForceConfig) *forceSim {
+	n := len(instances)
+	s := &forceSim{
+		px: make([]float64, n), py: make([]float64, n), pz: make([]float64, n),
+		dx: make([]float64, n), dy: make([]float64, n), dz: make([]float64, n),
+		theta:   fc.Theta,
+		gravity: fc.Gravity,
+	}
+
+	// Start uniformly inside a sphere holding one instance per unit volume
+	r0 := math.Cbrt(float64(n))
+	seed := fmt.Sprintf("%d:", fc.Seed)
+	for i := range instances {
+		d := seed + instances[i].Domain
+		theta := domainHash(d+"_theta") * 2 * math.Pi
+		phi := math.Acos(2*domainHash(d+"_phi") - 1)
+		r := r0 * math.Cbrt(domainHash(d+"_r"))
+		s.px[i] = r * math.Sin(phi) * math.Cos(theta)
+		s.py[i] = r * math.Sin(phi) * math.Sin(theta)
+		s.pz[i] = r * math.Cos(phi)
+	}
+
+	s.edges = peerEdges(instances, fc.MaxPeers)
+	return s
+}
+
+// peerEdges returns each undirected federation edge once. Every instance
+// contributes its maxPeers largest peers present in the dataset.
+func peerEdges(instances []Instance, maxPeers int) [][2]int32 {
+	index := make(map[string]int, len(instances))
+	for i := range instances {
+		index[normalizeDomain(instances[i].Domain)] = i
+	}
+
+	seen := make(map[[2]int32]bool)
+	var edges [][2]int32
+	for i := range instances {
+		var peers []int
+		for _, p := range instances[i].Peers {
+			if j, ok := index[p]; ok && j != i {
+				peers = append(peers, j)
+			}
+		}
+		sort.Slice(peers, func(a, b int) bool {
+			ua, ub := getInstanceUserCount(&instances[peers[a]]), getInstanceUserCount(&instances[peers[b]])
+			if ua != ub {
+				return ua > ub
+			}
+			return peers[a] < peers[b]
+		})
+		if len(peers) > maxPeers {
+			peers = peers[:maxPeers]
+		}
+		for _, j := range peers {
+			e := [2]int32{int32(i), int32(j)}
+			if e[0] > e[1] {
+				e[0], e[1] = e[1], e[0]
+			}
+			if !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+	sort.Slice(edges, func(a, b int) bool {
+		if edges[a][0] != edges[b][0] {
+			return edges[a][0] < edges[b][0]
+		}
+		return edges[a][1] < edges[b][1]
+	})
+	return edges
+}
+
+// run performs the given number of steps, cooling the maximum displacement
+// linearly so the layout settles
+func (s *forceSim) run(iterations int) {
+	n := len(s.px)
+	t0 := math.Cbrt(float64(n))/10 + 0.1
+	for it := 0; it < iterations; it++ {
+		temp := t0 * (1 - float64(it)/float64(iterations))
+		s.step(temp)
+	}
+}
+
+// step applies one round of forces with the ideal edge length k = 1:
+// repulsion 1/d between all pairs, attraction d^2 along edges
+func (s *forceSim) step(temp float64) {
+	n := len(s.px)
+	s.tree.build(s.px, s.py, s.pz)
+
+	// Visit bodies in tree order so neighbouring bodies share cached cells
+	for _, b := range s.tree.order {
+		i := int(b)
+		fx, fy, fz := s.tree.repulsion(i, s.px[i], s.py[i], s.pz[i], s.theta)
+		s.dx[i] = fx - s.gravity*s.px[i]
+		s.dy[i] = fy - s.gravity*s.py[i]
+		s.dz[i] = fz - s.gravity*s.pz[i]
+	}
+
+	for _, e := range s.edges {
+		i, j := e[0], e[1]
+		ex, ey, ez := s.px[i]-s.px[j], s.py[i]-s.py[j], s.pz[i]-s.pz[j]
+		d := math.Sqrt(ex*ex + ey*ey + ez*ez)
+		// F = d^2 along the unit vector, i.e. e * d
+		s.dx[i] -= ex * d
+		s.dy[i] -= ey * d
+		s.dz[i] -= ez * d
+		s.dx[j] += ex * d
+		s.dy[j] += ey * d
+		s.dz[j] += ez * d
+	}
+
+	for i := 0; i < n; i++ {
+		d := math.Sqrt(s.dx[i]*s.dx[i] + s.dy[i]*s.dy[i] + s.dz[i]*s.dz[i])
+		if d == 0 {
+			continue
+		}
+		limit := math.Min(d, temp) / d
+		s.px[i] += s.dx[i] * limit
+		s.py[i] += s.dy[i] * limit
+		s.pz[i] += s.dz[i] * limit
+	}
+}
+
+// ============================================================================
+// Barnes-Hut Octree
+// ============================================================================
+
+// bhMaxDepth stops subdivision for (nearly) coincident points
+const bhMaxDepth = 24
+
+type bhNode struct {
+	cx, cy, cz float64 // Cell center
+	half       float64 // Half the cell's edge length
+	mx, my, mz float64 // Center of mass
+	mass       float64
+	body       int32    // Sole body in a single-body leaf, else -1
+	children   [8]int32 // Child node indices, 0 = empty (the root is never a child)
+	leaf       bool
+}
+
+// bhTree is rebuilt every step; its buffers are reused between builds
+type bhTree struct {
+	nodes      []bhNode
+	order, tmp []int32
+	px, py, pz []float64
+}
+
+func (t *bhTree) build(px, py, pz []float64) {
+	n := len(px)
+	t.px, t.py, t.pz = px, py, pz
+	t.nodes = t.nodes[:0]
+	if cap(t.order) < n {
+		t.order = make([]int32, n)
+		t.tmp = make([]int32, n)
+	}
+	t.order, t.tmp = t.order[:n], t.tmp[:n]
+	for i := range t.order {
+		t.order[i] = int32(i)
+	}
+
+	minX, minY, minZ := math.Inf(1), math.Inf(1), math.Inf(1)
+	maxX, maxY, maxZ := math.Inf(-1), math.Inf(-1), math.Inf(-1)
+	for i := 0; i < n; i++ {
+		minX, maxX = math.Min(minX, px[i]), math.Max(maxX, px[i])
+		minY, maxY = math.Min(minY, py[i]), math.Max(maxY, py[i])
+		minZ, maxZ = math.Min(minZ, pz[i]), math.Max(maxZ, pz[i])
+	}
+	half := math.Max(maxX-minX, math.Max(maxY-minY, maxZ-minZ))/2 + 1e-9
+	t.subdivide(0, n, (minX+maxX)/2, (minY+maxY)/2, (minZ+maxZ)/2, half, 0)
+}
+
+// subdivide builds the node for bodies order[lo:hi] and returns its index
+func (t *bhTree) subdivide(lo, hi int, cx, cy, cz, half float64, depth int) int32 {
+	idx := int32(len(t.nodes))
+	t.nodes = append(t.nodes, bhNode{cx: cx, cy: cy, cz: cz, half: half, body: -1})
+
+	if hi-lo == 1 || depth >= bhMaxDepth {
+		var mx, my, mz float64
+		for _, b := range t.order[lo:hi] {
+			mx += t.px[b]
+			my += t.py[b]
+			mz += t.pz[b]
+		}
+		m := float64(hi - lo)
+		node := &t.nodes[idx]
+		node.leaf = true
+		node.mass = m
+		node.mx, node.my, node.mz = mx/m, my/m, mz/m
+		if hi-lo == 1 {
+			node.body = t.order[lo]
+		}
+		return idx
+	}
+
+	// Counting sort of the range by octant (bit 0 = +x, 1 = +y, 2 = +z)
+	var counts [8]int
+	octant := func(b int32) int {
+		o := 0
+		if t.px[b] >= cx {
+			o |= 1
+		}
+		if t.py[b] >= cy {
+			o |= 2
+		}
+		if t.pz[b] >= cz {
+			o |= 4
+		}
+		return o
+	}
+	for _, b := range t.order[lo:hi] {
+		counts[octant(b)]++
+	}
+	var starts [8]int
+	pos := lo
+	for o := 0; o < 8; o++ {
+		starts[o] = pos
+		pos += counts[o]
+	}
+	next := starts
+	for _, b := range t.order[lo:hi] {
+		o := octant(b)
+		t.tmp[next[o]] = b
+		next[o]++
+	}
+	copy(t.order[lo:hi], t.tmp[lo:hi])
+
+	var mass, mx, my, mz float64
+	q := half / 2
+	for o := 0; o < 8; o++ {
+		if counts[o] == 0 {
+			continue
+		}
+		ox, oy, oz := cx-q, cy-q, cz-q
+		if o&1 != 0 {
+			ox = cx + q
+		}
+		if o&2 != 0 {
+			oy = cy + q
+		}
+		if o&4 != 0 {
+			oz = cz + q
+		}
+		child := t.subdivide(starts[o], starts[o]+counts[o], ox, oy, oz, q, depth+1)
+		t.nodes[idx].children[o] = child
+		c := &t.nodes[child]
+		mass += c.mass
+		mx += c.mx * c.mass
+		my += c.my * c.mass
+		mz += c.mz * c.mass
+	}
+	node := &t.nodes[idx]
+	node.mass = mass
+	node.mx, node.my, node.mz = mx/mass, my/mass, mz/mass
+	return idx
+}
+
+// repulsion returns the total repulsive force on body i at (x, y, z). Cells
+// smaller than theta times their distance are treated as a single mass.
+func (t *bhTree) repulsion(i int, x, y, z, theta float64) (fx, fy, fz float64) {
+	var stack [8 * (bhMaxDepth + 1)]int32
+	sp := 0
+	stack[sp] = 0
+	sp++
+	for sp > 0 {
+		sp--
+		node := &t.nodes[stack[sp]]
+		if node.body == int32(i) {
+			continue
+		}
+		ex, ey, ez := x-node.mx, y-node.my, z-node.mz
+		d2 := ex*ex + ey*ey + ez*ez
+		if node.leaf || (2*node.half)*(2*node.half) < theta*theta*d2 {
+			if d2 < 1e-12 {
+				continue // Coincident with this cell's mass; no defined direction
+			}
+			// F = mass / d along the unit vector, i.e. e * mass / d^2
+			f := node.mass / d2
+			fx += ex * f
+			fy += ey * f
+			fz += ez * f
+			continue
+		}
+		for _, c := range node.children {
+			if c != 0 {
+				stack[sp] = c
+				sp++
+			}
+		}
+	}
+	return fx, fy, fz
+}
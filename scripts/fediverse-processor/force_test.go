@@ -0,0 +1,216 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ============================================================
+// A. Peer List Ingestion Tests
+// ============================================================
+
+func TestLoadPeerGraph_Directory(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.example.json"), []byte(`["B.example", "c.example.", "a.example", "b.example"]`), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`ignored`), 0644)
+
+	f, _ := os.Create(filepath.Join(dir, "b.example.json.gz"))
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte(`["a.example"]`))
+	zw.Close()
+	f.Close()
+
+	graph, err := LoadPeerGraph(dir)
+	if err != nil {
+		t.Fatalf("LoadPeerGraph failed: %v", err)
+	}
+	want := PeerGraph{
+		"a.example": {"b.example", "c.example"},
+		"b.example": {"a.example"},
+	}
+	if !reflect.DeepEqual(graph, want) {
+		t.Errorf("graph = %v, want %v", graph, want)
+	}
+}
+
+func TestLoadPeerGraph_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	os.WriteFile(path, []byte(`{"A.example": ["b.example"], "b.example": []}`), 0644)
+
+	graph, err := LoadPeerGraph(path)
+	if err != nil {
+		t.Fatalf("LoadPeerGraph failed: %v", err)
+	}
+	instances := []Instance{{Domain: "a.example"}, {Domain: "B.Example"}, {Domain: "c.example"}}
+	if matched := AttachPeers(instances, graph); matched != 2 {
+		t.Errorf("Expected 2 instances with peer lists, got %d", matched)
+	}
+	if !reflect.DeepEqual(instances[0].Peers, []string{"b.example"}) || instances[2].Peers != nil {
+		t.Errorf("Unexpected peers: %v / %v", instances[0].Peers, instances[2].Peers)
+	}
+
+	if _, err := LoadPeerGraph(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for a missing peer list")
+	}
+}
+
+// ============================================================
+// B. Federation Edge Tests
+// ============================================================
+
+func TestPeerEdges(t *testing.T) {
+	instances := []Instance{
+		{Domain: "a", Peers: []string{"b", "c", "d", "missing"}, Stats: &Stats{UserCount: 1}},
+		{Domain: "b", Peers: []string{"a"}, Stats: &Stats{UserCount: 10}},
+		{Domain: "c", Stats: &Stats{UserCount: 30}},
+		{Domain: "d", Stats: &Stats{UserCount: 20}},
+	}
+
+	// a keeps only its two largest peers (c, d); a-b comes from b's list
+	edges := peerEdges(instances, 2)
+	want := [][2]int32{{0, 1}, {0, 2}, {0, 3}}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+
+	edges = peerEdges(instances, 1)
+	want = [][2]int32{{0, 1}, {0, 2}}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("with max_peers 1: edges = %v, want %v", edges, want)
+	}
+}
+
+// ============================================================
+// C. Barnes-Hut Tests
+// ============================================================
+
+func TestBarnesHut_MatchesExactRepulsion(t *testing.T) {
+	var instances []Instance
+	for i := 0; i < 300; i++ {
+		instances = append(instances, Instance{Domain: fmt.Sprintf("n%d.example", i)})
+	}
+	sim := newForceSim(instances, DefaultConfig.Force)
+	sim.tree.build(sim.px, sim.py, sim.pz)
+
+	for _, i := range []int{0, 17, 299} {
+		var ex, ey, ez float64
+		for j := range instances {
+			if j == i {
+				continue
+			}
+			dx, dy, dz := sim.px[i]-sim.px[j], sim.py[i]-sim.py[j], sim.pz[i]-sim.pz[j]
+			d2 := dx*dx + dy*dy + dz*dz
+			ex, ey, ez = ex+dx/d2, ey+dy/d2, ez+dz/d2
+		}
+
+		fx, fy, fz := sim.tree.repulsion(i, sim.px[i], sim.py[i], sim.pz[i], 0)
+		if math.Abs(fx-ex)+math.Abs(fy-ey)+math.Abs(fz-ez) > 1e-9 {
+			t.Errorf("theta=0 force on %d = (%g,%g,%g), exact (%g,%g,%g)", i, fx, fy, fz, ex, ey, ez)
+		}
+
+		ax, ay, az := sim.tree.repulsion(i, sim.px[i], sim.py[i], sim.pz[i], 0.8)
+		errNorm := math.Sqrt((ax-ex)*(ax-ex) + (ay-ey)*(ay-ey) + (az-ez)*(az-ez))
+		if exact := math.Sqrt(ex*ex + ey*ey + ez*ez); errNorm > 0.1*exact {
+			t.Errorf("theta=0.8 force on %d is off by %.1f%%", i, 100*errNorm/exact)
+		}
+	}
+}
+
+// ============================================================
+// D. Force Layout Tests
+// ============================================================
+
+// clusteredFixture builds two cliques of federating instances
+func clusteredFixture() []Instance {
+	var instances []Instance
+	for c, prefix := range []string{"left", "right"} {
+		for i := 0; i < 20; i++ {
+			inst := Instance{Domain: fmt.Sprintf("%s%d.example", prefix, i), Stats: &Stats{UserCount: 100 * (c + 1)}}
+			for j := 0; j < 20; j++ {
+				if j != i {
+					inst.Peers = append(inst.Peers, fmt.Sprintf("%s%d.example", prefix, j))
+				}
+			}
+			instances = append(instances, inst)
+		}
+	}
+	return instances
+}
+
+func forceTestConfig() Config {
+	cfg := DefaultConfig
+	cfg.Layout = "force"
+	cfg.Force.Peers = "unused-in-tests"
+	cfg.Force.Iterations = 60
+	return cfg
+}
+
+func TestForceLayout_PeersClusterTogether(t *testing.T) {
+	result := ProcessPositions(clusteredFixture(), forceTestConfig())
+
+	dist := func(a, b *Position) float64 {
+		return math.Sqrt((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y) + (a.Z-b.Z)*(a.Z-b.Z))
+	}
+	var within, across float64
+	var nw, na int
+	for i := range result {
+		for j := i + 1; j < len(result); j++ {
+			d := dist(result[i].Position, result[j].Position)
+			if (i < 20) == (j < 20) {
+				within += d
+				nw++
+			} else {
+				across += d
+				na++
+			}
+		}
+	}
+	if within/float64(nw) >= across/float64(na)/2 {
+		t.Errorf("Peers should cluster: mean within %.0f, across %.0f", within/float64(nw), across/float64(na))
+	}
+
+	maxR := 0.0
+	for _, inst := range result {
+		p := inst.Position
+		maxR = math.Max(maxR, math.Sqrt(p.X*p.X+p.Y*p.Y+p.Z*p.Z))
+		if inst.PositionType != "satellite" && inst.PositionType != "asteroid" {
+			t.Errorf("%s: unexpected position type %q", inst.Domain, inst.PositionType)
+		}
+	}
+	if math.Abs(maxR-DefaultConfig.Force.Radius) > 1 {
+		t.Errorf("Layout should be scaled to radius %g, got %g", DefaultConfig.Force.Radius, maxR)
+	}
+}
+
+func TestForceLayout_Deterministic(t *testing.T) {
+	cfg := forceTestConfig()
+	a := ProcessPositions(clusteredFixture(), cfg)
+	b := ProcessPositions(clusteredFixture(), cfg)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Same seed and input should give identical positions")
+	}
+
+	cfg.Force.Seed = 2
+	c := ProcessPositions(clusteredFixture(), cfg)
+	if reflect.DeepEqual(a[0].Position, c[0].Position) {
+		t.Error("A different seed should change the layout")
+	}
+}
+
+func TestValidate_ForceLayout(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Layout = "force"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error when layout is force without force.peers")
+	}
+	cfg.Force.Peers = "data/peers"
+	cfg.Force.Theta = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a negative force.theta")
+	}
+}
@@ -1,3 +1,14 @@
 module fediverse-processor
 
 go 1.21
+
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/klauspost/compress v1.17.11
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/net v0.35.0
+	golang.org/x/text v0.22.0 // indirect
+)
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Instance file formats
+const (
+	FormatJSON   = "json"   // A single JSON array (the historical format)
+	FormatNDJSON = "ndjson" // One JSON object per line
+)
+
+var instanceFormats = []string{FormatJSON, FormatNDJSON, FormatBinary}
+
+// formatForPath returns the explicit format if set, otherwise the format
+// implied by the file extension (.ndjson/.jsonl, .bin, ignoring a compression
+// extension such as .gz), or "" if neither decides
+func formatForPath(path, explicit string) (string, error) {
+	if explicit != "" {
+		if !containsString(instanceFormats, explicit) {
+			return "", fmt.Errorf("unknown format %q (want one of %s)", explicit, strings.Join(instanceFormats, ", "))
+		}
+		return explicit, nil
+	}
+	path, _ = splitCompressionExt(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	case ".bin":
+		return FormatBinary, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", nil
+}
+
+// ============================================================================
+// Decoding
+// ============================================================================
+
+// InstanceDecoder reads instances one at a time, so a file never has to be
+// held in memory as raw bytes. Processing still needs every decoded instance
+// at once (ranks, tiers and duplicates span the whole dataset), so memory
+// grows with the number of instances.
+type InstanceDecoder struct {
+	dec     *json.Decoder
+	format  string
+	started bool
+	record  int
+}
+
+// NewInstanceDecoder returns a decoder for r. If format is "" it is sniffed
+// from the first non-space byte: '[' means a JSON array, anything else NDJSON.
+func NewInstanceDecoder(r io.Reader, format string) *InstanceDecoder {
+	if format == "" {
+		br := bufio.NewReader(r)
+		format = FormatNDJSON
+		for {
+			b, err := br.ReadByte()
+			if err != nil {
+				break
+			}
+			if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+				continue
+			}
+			if b == '[' {
+				format = FormatJSON
+			}
+			br.UnreadByte()
+			break
+		}
+		r = br
+	}
+	return &InstanceDecoder{dec: json.NewDecoder(r), format: format}
+}
+
+// Next returns the next instance, or io.EOF after the last one
+func (d *InstanceDecoder) Next() (Instance, error) {
+	if d.format == FormatJSON && !d.started {
+		d.started = true
+		tok, err := d.dec.Token()
+		if err == io.EOF {
+			return Instance{}, fmt.Errorf("cannot parse JSON: empty input")
+		} else if err != nil {
+			return Instance{}, fmt.Errorf("cannot parse JSON: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return Instance{}, fmt.Errorf("cannot parse JSON: expected an array of instances")
+		}
+	}
+
+	if d.format == FormatJSON && !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return Instance{}, fmt.Errorf("cannot parse JSON: %w", err)
+		}
+		return Instance{}, io.EOF
+	}
+
+	var inst Instance
+	d.record++
+	if err := d.dec.Decode(&inst); err == io.EOF && d.format == FormatNDJSON {
+		return Instance{}, io.EOF
+	} else if err != nil {
+		return Instance{}, fmt.Errorf("cannot parse %s record %d: %w", d.format, d.record, err)
+	}
+	return inst, nil
+}
+
+// DecodeInstances reads every instance from r into memory
+func DecodeInstances(r io.Reader, format string) ([]Instance, error) {
+	dec := NewInstanceDecoder(r, format)
+	var instances []Instance
+	for {
+		inst, err := dec.Next()
+		if err == io.EOF {
+			return instances, nil
+		} else if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+}
+
+// ============================================================================
+// Encoding
+// ============================================================================
+
+// InstanceEncoder writes instances one at a time. The JSON format produces
+// the same bytes as json.MarshalIndent of the whole array.
+type InstanceEncoder struct {
+	w      *bufio.Writer
+	format string
+	count  int
+}
+
+// NewInstanceEncoder returns an encoder writing format to w. Close must be
+// called to finish the output.
+func NewInstanceEncoder(w io.Writer, format string) *InstanceEncoder {
+	if format == "" {
+		format = FormatJSON
+	}
+	return &InstanceEncoder{w: bufio.NewWriter(w), format: format}
+}
+
+// Encode writes one instance
+func (e *InstanceEncoder) Encode(inst Instance) error {
+	var data []byte
+	var err error
+	if e.format == FormatNDJSON {
+		data, err = json.Marshal(inst)
+	} else {
+		data, err = json.MarshalIndent(inst, "  ", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot marshal JSON: %w", err)
+	}
+
+	switch {
+	case e.format == FormatNDJSON:
+		data = append(data, '\n')
+	case e.count == 0:
+		e.w.WriteString("[\n  ")
+	default:
+		e.w.WriteString(",\n  ")
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Close terminates the array (for JSON) and flushes buffered output
+func (e *InstanceEncoder) Close() error {
+	if e.format == FormatJSON {
+		if e.count == 0 {
+			e.w.WriteString("[]")
+		} else {
+			e.w.WriteString("\n]")
+		}
+	}
+	return e.w.Flush()
+}
+
+// EncodeInstances writes every instance to w
+func EncodeInstances(w io.Writer, format string, instances []Instance) error {
+	enc := NewInstanceEncoder(w, format)
+	for i := range instances {
+		if err := enc.Encode(instances[i]); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Format Selection Tests
+// ============================================================
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path, explicit, want string
+	}{
+		{"data/raw.json", "", FormatJSON},
+		{"data/raw.ndjson", "", FormatNDJSON},
+		{"data/raw.JSONL", "", FormatNDJSON},
+		{"-", "", ""},
+		{"data/raw.json", "ndjson", FormatNDJSON},
+	}
+	for _, tt := range tests {
+		got, err := formatForPath(tt.path, tt.explicit)
+		if err != nil || got != tt.want {
+			t.Errorf("formatForPath(%q, %q) = %q, %v; want %q", tt.path, tt.explicit, got, err, tt.want)
+		}
+	}
+	if _, err := formatForPath("x.json", "csv"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+// ============================================================
+// B. Streaming Decode/Encode Tests
+// ============================================================
+
+func ioFixture() []Instance {
+	open := true
+	return []Instance{
+		{Domain: "a.example", Stats: &Stats{UserCount: 10}, OpenRegistrations: &open},
+		{Domain: "b.example", Description: "<b>bold</b> & more", Software: &Software{Name: "Mastodon"}},
+		{Domain: "c.example", FirstSeenAt: "2020-01-01T00:00:00Z"},
+	}
+}
+
+func TestEncodeInstances_JSONMatchesMarshalIndent(t *testing.T) {
+	for _, instances := range [][]Instance{ioFixture(), ioFixture()[:1], {}} {
+		var buf bytes.Buffer
+		if err := EncodeInstances(&buf, FormatJSON, instances); err != nil {
+			t.Fatalf("EncodeInstances failed: %v", err)
+		}
+		want, _ := json.MarshalIndent(instances, "", "  ")
+		if buf.String() != string(want) {
+			t.Errorf("Streaming output differs from MarshalIndent:\n%s\nwant:\n%s", buf.String(), want)
+		}
+	}
+}
+
+func TestInstanceRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatNDJSON} {
+		var buf bytes.Buffer
+		if err := EncodeInstances(&buf, format, ioFixture()); err != nil {
+			t.Fatalf("%s: encode failed: %v", format, err)
+		}
+		if format == FormatNDJSON && strings.Count(buf.String(), "\n") != 3 {
+			t.Errorf("NDJSON should have one line per instance:\n%s", buf.String())
+		}
+
+		// Both explicit and sniffed formats must decode
+		for _, decodeAs := range []string{format, ""} {
+			got, err := DecodeInstances(bytes.NewReader(buf.Bytes()), decodeAs)
+			if err != nil {
+				t.Fatalf("%s (as %q): decode failed: %v", format, decodeAs, err)
+			}
+			if len(got) != 3 || got[1].Description != "<b>bold</b> & more" || got[0].Stats.UserCount != 10 {
+				t.Errorf("%s (as %q): unexpected round trip: %+v", format, decodeAs, got)
+			}
+		}
+	}
+}
+
+func TestInstanceDecoder_Streams(t *testing.T) {
+	// The decoder must hand out records before the array is complete
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte(`[{"domain":"first.example"},`))
+	}()
+
+	dec := NewInstanceDecoder(pr, FormatJSON)
+	inst, err := dec.Next()
+	if err != nil || inst.Domain != "first.example" {
+		t.Fatalf("Expected first record before EOF, got %+v, %v", inst, err)
+	}
+	pw.Close()
+}
+
+func TestInstanceDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		name, input, format, want string
+	}{
+		{"not an array", `{"domain":"a"}`, FormatJSON, "expected an array"},
+		{"truncated array", `[{"domain":"a"},`, FormatJSON, "record 2"},
+		{"bad ndjson line", "{\"domain\":\"a\"}\n{oops}\n", FormatNDJSON, "record 2"},
+		{"empty", "", FormatJSON, "empty input"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeInstances(strings.NewReader(tt.input), tt.format)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+// ============================================================
+// C. File I/O Tests
+// ============================================================
+
+func TestReadWriteInstances_ByExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	if err := WriteInstances(path, "", "", ioFixture()); err != nil {
+		t.Fatalf("WriteInstances failed: %v", err)
+	}
+	got, err := ReadInstances(path, "")
+	if err != nil || len(got) != 3 {
+		t.Fatalf("ReadInstances = %d instances, %v", len(got), err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Layout places instances in 3D space. Implementations register themselves
+// by name and are selected with the `layout` config key or -layout.
+type Layout interface {
+	Name() string
+	Description() string
+	// Place returns a copy of instances with Position and PositionType set
+	Place(instances []Instance, cfg Config) []Instance
+}
+
+// DefaultLayout is used when no layout is configured
+const DefaultLayout = "spiral"
+
+// layouts is the registry of available layouts, filled by registerLayout
+var layouts = make(map[string]Layout)
+
+// registerLayout adds l to the registry; it is called from init functions
+func registerLayout(l Layout) {
+	if _, dup := layouts[l.Name()]; dup {
+		panic(fmt.Sprintf("layout %q registered twice", l.Name()))
+	}
+	layouts[l.Name()] = l
+}
+
+// layoutNames returns the registered layout names in sorted order
+func layoutNames() []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findLayout looks up a layout by name ("" selects DefaultLayout)
+func findLayout(name string) (Layout, error) {
+	if name == "" {
+		name = DefaultLayout
+	}
+	l, ok := layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout %q (available: %s)", name, strings.Join(layoutNames(), ", "))
+	}
+	return l, nil
+}
+
+// ProcessPositions places instances with the layout named by cfg.Layout and
+// then spreads out instances closer than the configured separation. An
+// unknown layout (which Validate rejects) falls back to DefaultLayout.
+func ProcessPositions(instances []Instance, cfg Config) []Instance {
+	l, err := findLayout(cfg.Layout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v; using %s\n", err, DefaultLayout)
+		l = layouts[DefaultLayout]
+	}
+	result := l.Place(instances, cfg)
+	if cfg.Previous != "" && os.Getenv("VERBOSE") == "1" {
+		anchored := 0
+		for i := range result {
+			if result[i].Anchored {
+				anchored++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "⚓ Kept %d previous positions\n", anchored)
+	}
+	if n := ResolveCollisions(result, cfg.Separation); n > 0 && os.Getenv("VERBOSE") == "1" {
+		fmt.Fprintf(os.Stderr, "💥 Separated %d overlapping pairs (min distance %g)\n", n, cfg.Separation.MinDistance)
+	}
+	return result
+}
+
+// runLayouts implements `layouts list`
+func runLayouts(args []string) int {
+	if len(args) > 0 && args[0] != "list" {
+		fmt.Fprintf(os.Stderr, "USAGE:\n  fediverse-processor layouts list\n")
+		return 2
+	}
+	for _, name := range layoutNames() {
+		fmt.Printf("%-12s %s\n", name, layouts[name].Description())
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Layout Registry Tests
+// ============================================================
+
+// stubLayout places every instance at the origin
+type stubLayout struct{}
+
+func (stubLayout) Name() string        { return "stub" }
+func (stubLayout) Description() string { return "test layout" }
+
+func (stubLayout) Place(instances []Instance, cfg Config) []Instance {
+	result := make([]Instance, len(instances))
+	for i := range instances {
+		result[i] = instances[i]
+		result[i].Position = &Position{}
+		result[i].PositionType = "stub"
+	}
+	return result
+}
+
+func layoutFixture() []Instance {
+	return []Instance{
+		{Domain: "mastodon.social", Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 3000000}},
+		{Domain: "m1.test", Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 10000}},
+		{Domain: "m2.test", Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 50}},
+		{Domain: "p1.test", Software: &Software{Name: "Pixelfed"}, Stats: &Stats{UserCount: 3000}},
+		{Domain: "x.test", Stats: &Stats{UserCount: 5}},
+	}
+}
+
+func withStubLayout(t *testing.T) {
+	t.Helper()
+	registerLayout(stubLayout{})
+	t.Cleanup(func() { delete(layouts, "stub") })
+}
+
+func TestFindLayout(t *testing.T) {
+	l, err := findLayout("")
+	if err != nil || l.Name() != DefaultLayout {
+		t.Errorf("Empty name should select %s, got %v, %v", DefaultLayout, l, err)
+	}
+	if _, err := findLayout("nope"); err == nil || !strings.Contains(err.Error(), "spiral") {
+		t.Errorf("Expected unknown-layout error listing available layouts, got %v", err)
+	}
+}
+
+func TestRegisterLayout_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic registering a layout twice")
+		}
+	}()
+	registerLayout(spiralLayout{})
+}
+
+func TestProcessPositions_UsesConfiguredLayout(t *testing.T) {
+	withStubLayout(t)
+	instances := layoutFixture()
+
+	cfg := DefaultConfig
+	cfg.Layout = "stub"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Registered layout should validate: %v", err)
+	}
+	for _, inst := range ProcessPositions(instances, cfg) {
+		if inst.PositionType != "stub" {
+			t.Fatalf("Expected stub layout to place %s, got %s", inst.Domain, inst.PositionType)
+		}
+	}
+
+	// The default still dispatches to the spiral layout, then separates
+	// when a minimum distance is configured
+	cfg = DefaultConfig.clone()
+	cfg.Separation.MinDistance = 25
+	got := ProcessPositions(instances, cfg)
+	want := spiralLayout{}.Place(instances, cfg)
+	ResolveCollisions(want, cfg.Separation)
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Default ProcessPositions differs from the spiral layout")
+	}
+
+	// Unvalidated configs with an unknown layout fall back instead of panicking
+	cfg.Layout = "no-such-layout"
+	if !reflect.DeepEqual(ProcessPositions(instances, cfg), got) {
+		t.Error("Unknown layout should fall back to the default layout")
+	}
+}
+
+func TestLayoutSelection_ConfigAndFlag(t *testing.T) {
+	withStubLayout(t)
+
+	cfg, sources, err := ResolveConfig(ConfigLayers{Sets: []string{"layout=stub"}})
+	if err != nil || cfg.Layout != "stub" || sources["layout"] != "flag:-set" {
+		t.Errorf("-set layout: got %q from %q, %v", cfg.Layout, sources["layout"], err)
+	}
+	cfg, sources, _ = ResolveConfig(ConfigLayers{Layout: "stub"})
+	if cfg.Layout != "stub" || sources["layout"] != "flag:-layout" {
+		t.Errorf("-layout: got %q from %q", cfg.Layout, sources["layout"])
+	}
+
+	cfg.Layout = "nope"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected validation error for an unknown layout")
+	}
+}
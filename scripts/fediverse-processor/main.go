@@ -6,10 +6,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
 func main() {
+	// Dispatch subcommands before parsing the processing flags
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd.Run(os.Args[2:]))
+		}
+	}
+
 	// Parse command-line arguments
 	opts := ParseCLI()
 
@@ -23,18 +32,47 @@ func main() {
 		os.Setenv("VERBOSE", "1")
 	}
 
-	// Configuration
-	cfg := DefaultConfig
+	if opts.TilesDir != "" && opts.ColorOnly {
+		fmt.Fprintf(os.Stderr, "❌ -tiles needs positions and cannot be combined with -colors-only\n")
+		os.Exit(2)
+	}
+	if format, _ := formatForPath(opts.OutputFile, opts.OutputFormat); format == FormatBinary {
+		if codec, err := compressionForPath(opts.OutputFile, opts.Compression); err == nil && codec != CompressionNone {
+			fmt.Fprintf(os.Stderr, "❌ binary output cannot be compressed; drop -output-compression (or use -precompress for the main file)\n")
+			os.Exit(2)
+		}
+	}
+	if opts.Precompress {
+		if opts.OutputFile == "-" {
+			fmt.Fprintf(os.Stderr, "❌ -precompress needs an output file, not stdout\n")
+			os.Exit(2)
+		}
+		if codec, err := compressionForPath(opts.OutputFile, opts.Compression); err == nil && codec != CompressionNone {
+			fmt.Fprintf(os.Stderr, "❌ -precompress needs an uncompressed output file\n")
+			os.Exit(2)
+		}
+	}
 
-	// Load configuration from file if specified
-	if opts.ConfigFile != "" {
-		if opts.Verbose {
+	// Configuration: defaults < preset < file < env < -set flags
+	if opts.Verbose {
+		if opts.Preset != "" {
+			fmt.Fprintf(os.Stderr, "🎛️  Applying preset: %s\n", opts.Preset)
+		}
+		if opts.ConfigFile != "" {
 			fmt.Fprintf(os.Stderr, "📋 Loading configuration from: %s\n", opts.ConfigFile)
 		}
-		// TODO: Load config from file (YAML/JSON)
-		// newCfg, err := LoadConfig(opts.ConfigFile)
-		// if err != nil { ... }
-		// cfg = newCfg
+	}
+	cfg, sources, err := ResolveConfig(opts.configLayers())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.Verbose {
+		PrintConfig(os.Stderr, cfg, sources)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
 	}
 
 	// Header
@@ -47,7 +85,7 @@ func main() {
 	if opts.Verbose {
 		fmt.Fprintf(os.Stderr, "📂 Loading instances from: %s\n", opts.InputFile)
 	}
-	instances, err := ReadInstances(opts.InputFile)
+	instances, err := ReadInstances(opts.InputFile, opts.InputFormat)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Failed to load input: %v\n", err)
 		os.Exit(1)
@@ -56,28 +94,97 @@ func main() {
 		fmt.Fprintf(os.Stderr, "✅ Loaded %d instances\n\n", len(instances))
 	}
 
+	// The force layout places instances by their federation peers
+	if cfg.Layout == "force" && !opts.ColorOnly {
+		graph, err := LoadPeerGraph(cfg.Force.Peers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load peers: %v\n", err)
+			os.Exit(1)
+		}
+		matched := AttachPeers(instances, graph)
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "🕸️  Loaded peer lists for %d of %d instances\n\n", matched, len(instances))
+		}
+	}
+
+	// Mapped nebulae group unknown software by an explicit domain list
+	if cfg.Dust.NebulaKey == NebulaKeyMapping && !opts.ColorOnly {
+		nebulae, err := LoadNebulaMap(cfg.Dust.NebulaMap)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load nebula map: %v\n", err)
+			os.Exit(1)
+		}
+		matched := AttachNebulaMap(instances, nebulae)
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "🌫️  Mapped %d of %d instances to nebulae\n\n", matched, len(instances))
+		}
+	}
+
+	// Stable placement keeps positions from an earlier output
+	if cfg.Previous != "" && !opts.ColorOnly {
+		previous, err := ReadInstances(cfg.Previous, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load previous output: %v\n", err)
+			os.Exit(1)
+		}
+		matched := AttachPrevious(instances, previous)
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "⚓ Found previous positions for %d of %d instances\n\n", matched, len(instances))
+		}
+	}
+
 	// Step 2-3: Process instances (colors and/or positions)
 	startTime := time.Now()
-	instances = ProcessInstances(instances, cfg, opts)
+	instances, report := ProcessInstances(instances, cfg, opts)
 	totalDuration := time.Since(startTime)
 
 	// Step 4: Save output
 	if opts.Verbose {
 		fmt.Fprintf(os.Stderr, "💾 Saving output to: %s\n", opts.OutputFile)
 	}
-	if err := WriteInstances(opts.OutputFile, instances); err != nil {
+	outputFormat, _ := formatForPath(opts.OutputFile, opts.OutputFormat)
+	if outputFormat == FormatBinary {
+		err = WriteBinaryInstances(opts.OutputFile, instances, opts.BinaryScale)
+	} else {
+		err = WriteInstances(opts.OutputFile, opts.OutputFormat, opts.Compression, instances)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Failed to save output: %v\n", err)
 		os.Exit(1)
 	}
+	if opts.Precompress {
+		written := []string{opts.OutputFile}
+		if outputFormat == FormatBinary {
+			written = append(written, binaryMetaPath(opts.OutputFile))
+		}
+		for _, path := range written {
+			if err := WritePrecompressed(path); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Failed to precompress output: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+	if opts.TilesDir != "" {
+		tileOpts := TileOptions{MaxPerTile: opts.TileSize, MaxDepth: opts.TileDepth}
+		compression, _ := compressionForPath(opts.OutputFile, opts.Compression)
+		root := BuildOctree(instances, tileOpts)
+		if err := WriteTileset(opts.TilesDir, root, tileOpts, outputFormat, compression, opts.BinaryScale); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to save tiles: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.Verbose {
+			fmt.Fprintf(os.Stderr, "🧊 Wrote %d instances as octree tiles to %s\n", root.Total, opts.TilesDir)
+		}
+	}
 	if opts.Verbose {
 		fmt.Fprintf(os.Stderr, "✅ Saved successfully\n\n")
 	}
 
 	// Step 5: Print statistics
 	if opts.JSONOutput {
-		PrintStatisticsJSON(instances)
+		PrintStatisticsJSON(instances, report)
 	} else if opts.OutputFile != "-" {
-		printStatistics(instances)
+		printStatistics(instances, report)
 		fmt.Println("\n📊 Processing Summary:")
 		fmt.Println("─────────────────────────────────────")
 		fmt.Printf("Total instances: %d\n", len(instances))
@@ -125,7 +232,7 @@ func saveInstances(path string, instances []Instance) error {
 }
 
 // printStatistics shows analysis of processed data
-func printStatistics(instances []Instance) {
+func printStatistics(instances []Instance, report *ProcessReport) {
 	fmt.Println("📈 Statistics:")
 	fmt.Println("─────────────────────────────────────")
 
@@ -167,6 +274,19 @@ func printStatistics(instances []Instance) {
 		fmt.Printf("  %-25s %5d instances\n", posType, count)
 	}
 
+	// Unknown-software dust strategies
+	if dust := buildDustStats(instances); len(dust) > 0 {
+		fmt.Println("\nDust strategies (unknown software):")
+		strategies := make([]string, 0, len(dust))
+		for s := range dust {
+			strategies = append(strategies, s)
+		}
+		sort.Strings(strategies)
+		for _, s := range strategies {
+			fmt.Printf("  %-25s %5d instances\n", s, dust[s])
+		}
+	}
+
 	// Color statistics
 	if len(instances) > 0 && instances[0].Color != nil {
 		var minHue, maxHue, sumHue float64
@@ -190,6 +310,27 @@ func printStatistics(instances []Instance) {
 		fmt.Printf("  Hue average: %.1f°\n", avgHue)
 	}
 
+	// Data quality
+	if len(report.TimestampIssues) > 0 || len(report.Duplicates) > 0 {
+		fmt.Println("\nData quality:")
+	}
+	if n := len(report.Duplicates); n > 0 {
+		fmt.Printf("  Duplicated domains merged: %d (policy: %s)\n", n, report.DuplicatePolicy)
+		for _, dup := range report.Duplicates {
+			fmt.Printf("    %-30s %s\n", dup.Domain, strings.Join(dup.Variants, ", "))
+		}
+	}
+	if n := len(report.TimestampIssues); n > 0 {
+		fmt.Printf("  Unparseable creation dates: %d (fallback: %s)\n", n, report.TimestampFallback)
+		for i := 0; i < n && i < 5; i++ {
+			issue := report.TimestampIssues[i]
+			fmt.Printf("    %-30s %s\n", issue.Domain, issue.Reason)
+		}
+		if n > 5 {
+			fmt.Printf("    ... and %d more (see -json output)\n", n-5)
+		}
+	}
+
 	// Sample instances
 	fmt.Println("\n📋 Sample Results (first 5):")
 	fmt.Println("─────────────────────────────────────")
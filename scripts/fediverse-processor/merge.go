@@ -0,0 +1,423 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Merge Policies
+// ============================================================================
+
+// Conflict resolution policies for a field supplied by several sources
+const (
+	MergePriority = "priority" // Source listed last wins
+	MergeFreshest = "freshest" // Source fetched most recently wins
+	MergeMax      = "max"      // Largest value wins (numeric fields)
+	MergeMin      = "min"      // Smallest value wins (numeric fields)
+	MergeEarliest = "earliest" // Oldest timestamp wins (date fields)
+)
+
+// mergeField describes how to read and copy one mergeable Instance field
+type mergeField struct {
+	name string
+	kind string // "string", "number" or "time"
+	get  func(inst *Instance) (string, bool)
+	set  func(dst, src *Instance)
+}
+
+var mergeFields = []mergeField{
+	{
+		name: "name", kind: "string",
+		get: func(i *Instance) (string, bool) { return i.Name, i.Name != "" },
+		set: func(d, s *Instance) { d.Name = s.Name },
+	},
+	{
+		name: "description", kind: "string",
+		get: func(i *Instance) (string, bool) { return i.Description, i.Description != "" },
+		set: func(d, s *Instance) { d.Description = s.Description },
+	},
+	{
+		name: "software.name", kind: "string",
+		get: func(i *Instance) (string, bool) {
+			if i.Software == nil || i.Software.Name == "" {
+				return "", false
+			}
+			return i.Software.Name, true
+		},
+		set: func(d, s *Instance) { ensureSoftware(d).Name = s.Software.Name },
+	},
+	{
+		name: "software.version", kind: "string",
+		get: func(i *Instance) (string, bool) {
+			if i.Software == nil || i.Software.Version == "" {
+				return "", false
+			}
+			return i.Software.Version, true
+		},
+		set: func(d, s *Instance) { ensureSoftware(d).Version = s.Software.Version },
+	},
+	{
+		name: "stats.user_count", kind: "number",
+		get: func(i *Instance) (string, bool) {
+			if i.Stats == nil {
+				return "", false
+			}
+			return strconv.Itoa(i.Stats.UserCount), true
+		},
+		set: func(d, s *Instance) { ensureStats(d).UserCount = s.Stats.UserCount },
+	},
+	{
+		name: "stats.monthly_active_users", kind: "number",
+		get: func(i *Instance) (string, bool) {
+			if i.Stats == nil {
+				return "", false
+			}
+			return strconv.Itoa(i.Stats.MonthlyActiveUsers), true
+		},
+		set: func(d, s *Instance) { ensureStats(d).MonthlyActiveUsers = s.Stats.MonthlyActiveUsers },
+	},
+	{
+		name: "open_registrations", kind: "string",
+		get: func(i *Instance) (string, bool) {
+			if i.OpenRegistrations == nil {
+				return "", false
+			}
+			return strconv.FormatBool(*i.OpenRegistrations), true
+		},
+		set: func(d, s *Instance) {
+			v := *s.OpenRegistrations
+			d.OpenRegistrations = &v
+		},
+	},
+	{
+		name: "first_seen_at", kind: "time",
+		get: func(i *Instance) (string, bool) { return i.FirstSeenAt, i.FirstSeenAt != "" },
+		set: func(d, s *Instance) { d.FirstSeenAt = s.FirstSeenAt },
+	},
+	{
+		name: "creation_time", kind: "time",
+		get: func(i *Instance) (string, bool) {
+			if i.CreationTime == nil || i.CreationTime.CreatedAt == "" {
+				return "", false
+			}
+			return i.CreationTime.CreatedAt, true
+		},
+		set: func(d, s *Instance) {
+			ct := *s.CreationTime
+			d.CreationTime = &ct
+		},
+	},
+}
+
+func ensureSoftware(inst *Instance) *Software {
+	if inst.Software == nil {
+		inst.Software = &Software{}
+	}
+	return inst.Software
+}
+
+func ensureStats(inst *Instance) *Stats {
+	if inst.Stats == nil {
+		inst.Stats = &Stats{}
+	}
+	return inst.Stats
+}
+
+// policiesFor lists the policies valid for a field kind
+func policiesFor(kind string) []string {
+	switch kind {
+	case "number":
+		return []string{MergePriority, MergeFreshest, MergeMax, MergeMin}
+	case "time":
+		return []string{MergePriority, MergeFreshest, MergeEarliest}
+	default:
+		return []string{MergePriority, MergeFreshest}
+	}
+}
+
+func findMergeField(name string) (mergeField, bool) {
+	for _, f := range mergeFields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return mergeField{}, false
+}
+
+// ============================================================================
+// Merge
+// ============================================================================
+
+// SourceDataset is one named input to the merge
+type SourceDataset struct {
+	Name      string
+	Fetched   time.Time // When the data was collected, for the "freshest" policy
+	Instances []Instance
+}
+
+// MergeRules selects the conflict policy per field
+type MergeRules struct {
+	Default string            // Policy for fields not listed in Fields
+	Fields  map[string]string // Field name (e.g. "stats.user_count") -> policy
+}
+
+// policy returns the policy that applies to field
+func (r MergeRules) policy(field string) string {
+	if p, ok := r.Fields[field]; ok {
+		return p
+	}
+	if r.Default != "" {
+		return r.Default
+	}
+	return MergePriority
+}
+
+// Validate checks that every rule names a known field and a policy valid for it
+func (r MergeRules) Validate() error {
+	var problems []string
+	for field, policy := range r.Fields {
+		f, ok := findMergeField(field)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown merge field %q", field))
+			continue
+		}
+		if !containsString(policiesFor(f.kind), policy) {
+			problems = append(problems, fmt.Sprintf("policy %q is not valid for %s (want one of %s)",
+				policy, field, strings.Join(policiesFor(f.kind), ", ")))
+		}
+	}
+	if r.Default != "" && r.Default != MergePriority && r.Default != MergeFreshest {
+		problems = append(problems, fmt.Sprintf("default policy %q must be %s or %s", r.Default, MergePriority, MergeFreshest))
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid merge rules: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// MergeSummary reports what a merge did
+type MergeSummary struct {
+	Records   map[string]int // Records read per source
+	Instances int            // Distinct domains in the output
+	Conflicts map[string]int // Domains where sources disagreed, per field
+}
+
+// mergeCandidate is one source's record for a domain
+type mergeCandidate struct {
+	source int
+	inst   *Instance
+}
+
+// MergeSources joins the datasets by normalized domain and resolves each field
+// according to rules. Sources are listed from lowest to highest priority.
+// Each output instance records in Provenance which source supplied each field,
+// or the winning record's own provenance when it came from an earlier merge.
+// Domains are written as the highest-priority source spells them (see
+// displayDomain), like DedupeInstances does.
+func MergeSources(sources []SourceDataset, rules MergeRules) ([]Instance, MergeSummary) {
+	summary := MergeSummary{
+		Records:   make(map[string]int),
+		Conflicts: make(map[string]int),
+	}
+
+	var order []string
+	byDomain := make(map[string][]mergeCandidate)
+	for si := range sources {
+		summary.Records[sources[si].Name] += len(sources[si].Instances)
+		for ii := range sources[si].Instances {
+			inst := &sources[si].Instances[ii]
+			domain := normalizeDomain(inst.Domain)
+			if domain == "" {
+				continue
+			}
+			if _, seen := byDomain[domain]; !seen {
+				order = append(order, domain)
+			}
+			byDomain[domain] = append(byDomain[domain], mergeCandidate{source: si, inst: inst})
+		}
+	}
+
+	result := make([]Instance, 0, len(order))
+	for _, domain := range order {
+		cands := byDomain[domain]
+		merged := Instance{
+			Domain:     displayDomain(cands[len(cands)-1].inst.Domain),
+			Provenance: make(map[string]string),
+		}
+
+		for _, field := range mergeFields {
+			var present []mergeCandidate
+			distinct := make(map[string]bool)
+			for _, c := range cands {
+				if v, ok := field.get(c.inst); ok {
+					present = append(present, c)
+					distinct[v] = true
+				}
+			}
+			if len(present) == 0 {
+				continue
+			}
+			if len(distinct) > 1 {
+				summary.Conflicts[field.name]++
+			}
+
+			winner := pickCandidate(present, field, rules.policy(field.name), sources)
+			field.set(&merged, winner.inst)
+			if origin, ok := winner.inst.Provenance[field.name]; ok {
+				merged.Provenance[field.name] = origin
+			} else {
+				merged.Provenance[field.name] = sources[winner.source].Name
+			}
+		}
+
+		result = append(result, merged)
+	}
+
+	summary.Instances = len(result)
+	return result, summary
+}
+
+// pickCandidate chooses the winning record for a field. Candidates arrive in
+// priority order, so on ties the later (higher-priority) one wins.
+func pickCandidate(cands []mergeCandidate, field mergeField, policy string, sources []SourceDataset) mergeCandidate {
+	best := cands[0]
+	for _, c := range cands[1:] {
+		if candidateBeats(c, best, field, policy, sources) {
+			best = c
+		}
+	}
+	return best
+}
+
+// candidateBeats reports whether c should replace best (ties go to c)
+func candidateBeats(c, best mergeCandidate, field mergeField, policy string, sources []SourceDataset) bool {
+	cv, _ := field.get(c.inst)
+	bv, _ := field.get(best.inst)
+
+	switch policy {
+	case MergeFreshest:
+		return !sources[c.source].Fetched.Before(sources[best.source].Fetched)
+	case MergeMax, MergeMin:
+		cn, _ := strconv.ParseFloat(cv, 64)
+		bn, _ := strconv.ParseFloat(bv, 64)
+		if policy == MergeMax {
+			return cn >= bn
+		}
+		return cn <= bn
+	case MergeEarliest:
+		ct, cerr := parseTimeStrict(cv)
+		bt, berr := parseTimeStrict(bv)
+		if cerr != nil {
+			return berr != nil // Unparseable dates only win against other unparseable dates
+		}
+		return berr != nil || !ct.After(bt)
+	default:
+		return true
+	}
+}
+
+// ============================================================================
+// Subcommand
+// ============================================================================
+
+// parseSourceSpec parses "name=path" or "name=path@2026-01-02" (the fetch date
+// used by the freshest policy; defaults to the file's modification time)
+func parseSourceSpec(spec string) (name, path string, fetched time.Time, err error) {
+	name, path, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || path == "" {
+		return "", "", time.Time{}, fmt.Errorf("source %q: expected name=path[@date]", spec)
+	}
+	if at := strings.LastIndex(path, "@"); at > 0 {
+		if t, perr := parseTimeStrict(path[at+1:]); perr == nil {
+			return name, path[:at], t, nil
+		}
+	}
+	if path != "-" {
+		if info, serr := os.Stat(path); serr == nil {
+			fetched = info.ModTime()
+		}
+	}
+	return name, path, fetched, nil
+}
+
+// runMerge implements the `merge` subcommand
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("output", "-", "Merged output JSON file (use '-' for stdout)")
+	defaultRule := fs.String("default-rule", MergePriority, "Policy for fields without a -rule: priority or freshest")
+	var ruleFlags stringList
+	fs.Var(&ruleFlags, "rule", "Per-field policy as field=policy (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE:\n  fediverse-processor merge [options] name=path[@date] ...\n\n")
+		fmt.Fprintf(os.Stderr, "Joins instance datasets by domain. Sources are listed from lowest to highest\n")
+		fmt.Fprintf(os.Stderr, "priority; each output field records the source that supplied it.\n\nOPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nFIELDS AND POLICIES:\n")
+		for _, f := range mergeFields {
+			fmt.Fprintf(os.Stderr, "  %-28s %s\n", f.name, strings.Join(policiesFor(f.kind), ", "))
+		}
+		fmt.Fprintf(os.Stderr, `
+EXAMPLE:
+  fediverse-processor merge -rule stats.user_count=max -rule first_seen_at=earliest \
+    fedidb=data/fedidb.json nodeinfo=data/crawl.json@2026-01-02 fixes=data/fixes.json
+`)
+	}
+	fs.Parse(args)
+
+	rules := MergeRules{Default: *defaultRule, Fields: make(map[string]string)}
+	for _, r := range ruleFlags {
+		field, policy, ok := strings.Cut(r, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "❌ -rule %q: expected field=policy\n", r)
+			return 2
+		}
+		rules.Fields[field] = policy
+	}
+	if err := rules.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var sources []SourceDataset
+	for _, spec := range fs.Args() {
+		name, path, fetched, err := parseSourceSpec(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 2
+		}
+		instances, err := ReadInstances(path, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load source %s: %v\n", name, err)
+			return 1
+		}
+		sources = append(sources, SourceDataset{Name: name, Fetched: fetched, Instances: instances})
+	}
+
+	merged, summary := MergeSources(sources, rules)
+	if err := WriteInstances(*output, "", "", merged); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to save output: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "🔀 Merged %d sources into %d instances\n", len(sources), summary.Instances)
+	for _, src := range sources {
+		fmt.Fprintf(os.Stderr, "  %-20s %6d records\n", src.Name, summary.Records[src.Name])
+	}
+	for _, f := range mergeFields {
+		if n := summary.Conflicts[f.name]; n > 0 {
+			fmt.Fprintf(os.Stderr, "  conflicts on %-26s %6d (%s)\n", f.name, n, rules.policy(f.name))
+		}
+	}
+	return 0
+}
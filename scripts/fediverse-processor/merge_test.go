@@ -0,0 +1,177 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// ============================================================
+// A. Multi-Source Merge Tests
+// ============================================================
+
+func mergeFixture() []SourceDataset {
+	return []SourceDataset{
+		{
+			Name:    "fedidb",
+			Fetched: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Instances: []Instance{
+				{
+					Domain:      "Mastodon.Social",
+					Description: "The original server",
+					Software:    &Software{Name: "Mastodon", Version: "4.2.0"},
+					Stats:       &Stats{UserCount: 2000000, MonthlyActiveUsers: 250000},
+					FirstSeenAt: "2017-03-01T00:00:00Z",
+				},
+				{Domain: "only.fedidb", Software: &Software{Name: "Pleroma"}},
+			},
+		},
+		{
+			Name:    "nodeinfo",
+			Fetched: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			Instances: []Instance{
+				{
+					Domain:   "mastodon.social.",
+					Software: &Software{Name: "Mastodon", Version: "4.3.1"},
+					Stats:    &Stats{UserCount: 1900000, MonthlyActiveUsers: 260000},
+				},
+			},
+		},
+		{
+			Name:    "fixes",
+			Fetched: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			Instances: []Instance{
+				{Domain: "mastodon.social", FirstSeenAt: "2016-10-05T00:00:00Z", Name: "Mastodon"},
+			},
+		},
+	}
+}
+
+func TestMergeSources_JoinsByNormalizedDomain(t *testing.T) {
+	merged, summary := MergeSources(mergeFixture(), MergeRules{})
+
+	if len(merged) != 2 || summary.Instances != 2 {
+		t.Fatalf("Expected 2 merged instances, got %d", len(merged))
+	}
+	if merged[0].Domain != "mastodon.social" || merged[1].Domain != "only.fedidb" {
+		t.Errorf("Unexpected domains/order: %s, %s", merged[0].Domain, merged[1].Domain)
+	}
+	if summary.Records["fedidb"] != 2 || summary.Records["nodeinfo"] != 1 {
+		t.Errorf("Unexpected record counts: %+v", summary.Records)
+	}
+}
+
+func TestMergeSources_PriorityAndProvenance(t *testing.T) {
+	merged, summary := MergeSources(mergeFixture(), MergeRules{})
+	ms := merged[0]
+
+	// Default priority: later sources win where they supply a field
+	tests := []struct {
+		field, source string
+	}{
+		{"name", "fixes"},
+		{"description", "fedidb"},
+		{"software.version", "nodeinfo"},
+		{"stats.user_count", "nodeinfo"},
+		{"first_seen_at", "fixes"},
+	}
+	for _, tt := range tests {
+		if got := ms.Provenance[tt.field]; got != tt.source {
+			t.Errorf("Provenance[%s] = %q, want %q", tt.field, got, tt.source)
+		}
+	}
+	if ms.Stats.UserCount != 1900000 || ms.Software.Version != "4.3.1" || ms.FirstSeenAt != "2016-10-05T00:00:00Z" {
+		t.Errorf("Unexpected merged values: %+v %+v %s", ms.Stats, ms.Software, ms.FirstSeenAt)
+	}
+	if summary.Conflicts["stats.user_count"] != 1 || summary.Conflicts["software.name"] != 0 {
+		t.Errorf("Unexpected conflict counts: %+v", summary.Conflicts)
+	}
+}
+
+func TestMergeSources_KeepsUnicodeDomain(t *testing.T) {
+	sources := []SourceDataset{
+		{Name: "a", Instances: []Instance{{Domain: "xn--bcher-kva.example"}}},
+		{Name: "b", Instances: []Instance{{Domain: "Bücher.Example."}}},
+	}
+	merged, _ := MergeSources(sources, MergeRules{})
+	if len(merged) != 1 || merged[0].Domain != "bücher.example" {
+		t.Errorf("Expected one instance spelled bücher.example like DedupeInstances, got %+v", merged)
+	}
+}
+
+func TestMergeSources_CarriesProvenanceForward(t *testing.T) {
+	first, _ := MergeSources(mergeFixture(), MergeRules{})
+	sources := []SourceDataset{
+		{Name: "merged", Instances: first},
+		{Name: "manual", Instances: []Instance{{Domain: "mastodon.social", Description: "Flagship"}}},
+	}
+	merged, _ := MergeSources(sources, MergeRules{})
+	ms := merged[0]
+
+	tests := []struct {
+		field, source string
+	}{
+		{"name", "fixes"},
+		{"stats.user_count", "nodeinfo"},
+		{"description", "manual"},
+	}
+	for _, tt := range tests {
+		if got := ms.Provenance[tt.field]; got != tt.source {
+			t.Errorf("Provenance[%s] = %q, want %q", tt.field, got, tt.source)
+		}
+	}
+}
+
+func TestMergeSources_FieldPolicies(t *testing.T) {
+	rules := MergeRules{
+		Default: MergeFreshest,
+		Fields: map[string]string{
+			"stats.user_count": MergeMax,
+			"first_seen_at":    MergeEarliest,
+		},
+	}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Rules should be valid: %v", err)
+	}
+
+	sources := mergeFixture()
+	sources[2].Instances[0].FirstSeenAt = "2018-01-01T00:00:00Z"
+	merged, _ := MergeSources(sources, rules)
+	ms := merged[0]
+
+	if ms.Stats.UserCount != 2000000 || ms.Provenance["stats.user_count"] != "fedidb" {
+		t.Errorf("max should pick fedidb's 2000000, got %d from %s", ms.Stats.UserCount, ms.Provenance["stats.user_count"])
+	}
+	if ms.Stats.MonthlyActiveUsers != 260000 || ms.Provenance["stats.monthly_active_users"] != "nodeinfo" {
+		t.Errorf("freshest should pick nodeinfo's MAU, got %d", ms.Stats.MonthlyActiveUsers)
+	}
+	if ms.FirstSeenAt != "2017-03-01T00:00:00Z" || ms.Provenance["first_seen_at"] != "fedidb" {
+		t.Errorf("earliest should pick fedidb's date, got %s", ms.FirstSeenAt)
+	}
+}
+
+func TestMergeRules_Validate(t *testing.T) {
+	bad := []MergeRules{
+		{Fields: map[string]string{"stats.users": MergeMax}},
+		{Fields: map[string]string{"name": MergeMax}},
+		{Fields: map[string]string{"first_seen_at": MergeMin}},
+		{Default: MergeMax},
+	}
+	for _, r := range bad {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Expected rules %+v to be invalid", r)
+		}
+	}
+}
+
+func TestParseSourceSpec(t *testing.T) {
+	name, path, fetched, err := parseSourceSpec("nodeinfo=data/crawl.json@2026-01-02")
+	if err != nil {
+		t.Fatalf("parseSourceSpec failed: %v", err)
+	}
+	if name != "nodeinfo" || path != "data/crawl.json" || !fetched.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected parse: %s %s %v", name, path, fetched)
+	}
+	if _, _, _, err := parseSourceSpec("no-equals"); err == nil {
+		t.Error("Expected error for spec without name")
+	}
+}
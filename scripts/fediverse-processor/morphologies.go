@@ -0,0 +1,218 @@
+package main
+
+import (
+	"math"
+)
+
+// ============================================================================
+// Galaxy Morphologies
+// ============================================================================
+//
+// Alternative overall shapes for special editions. Each one reuses the spiral
+// pipeline (placeGalaxy): software tiers from calculateSystemTier, systems
+// filled by calculateInstancePosition, supergiants in the core. Only the
+// system centers and the unknown-software dust differ.
+
+func init() {
+	registerLayout(barredLayout{})
+	registerLayout(ellipticalLayout{})
+	registerLayout(ringLayout{})
+}
+
+// hashGaussian returns a standard normal value derived from seed (Box-Muller)
+func hashGaussian(seed string) float64 {
+	u1 := math.Max(domainHash(seed+"_u1"), 1e-12)
+	u2 := domainHash(seed + "_u2")
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// ============================================================================
+// Barred Spiral
+// ============================================================================
+
+// barredLayout lays Tier C systems along a central bar and winds the Tier A
+// arms out of the bar's ends
+type barredLayout struct{}
+
+func (barredLayout) Name() string { return "barred" }
+
+func (barredLayout) Description() string {
+	return "Barred spiral: small systems along a central bar that feeds the arms"
+}
+
+func (barredLayout) Place(instances []Instance, cfg Config) []Instance {
+	return placeGalaxy(instances, cfg, calculateBarredCenters, calculateBarredDust)
+}
+
+func calculateBarredCenters(softwareTiers map[string]TierInfo, cfg Config) map[string]Position {
+	centers := make(map[string]Position)
+	g := cfg.Galaxy
+	tierA, tierB, tierC := splitTiers(softwareTiers)
+
+	// Tier A: arm k leaves the +x end of the bar (even k) or the -x end
+	// (odd k); later arms from the same end are rotated further round
+	for i := range tierA {
+		tierA[i].ArmIndex = i % g.Arms
+	}
+	for _, tierInfo := range tierA {
+		k := tierInfo.ArmIndex
+		baseAngle := math.Pi*float64(k%2) + 2*math.Pi*float64(k/2)/float64(g.Arms)
+
+		armPosition := getArmMemberIndex(tierInfo.Software, tierA, k)
+		totalInArm := countSoftwareInArm(tierA, k)
+		progress := float64(armPosition) / math.Max(float64(totalInArm), 1.0)
+		theta := progress * 2 * g.Turns * math.Pi
+
+		radius := g.BarLength * math.Exp(g.Pitch*theta)
+		angle := baseAngle + theta
+
+		zHash := domainHash(tierInfo.Software + "_z")
+		z := math.Sin(theta*2.0)*radius*g.WaveAmplitude + (zHash-0.5)*radius*0.05
+
+		centers[tierInfo.Software] = Position{
+			X: radius * math.Cos(angle),
+			Y: radius * math.Sin(angle),
+			Z: z,
+		}
+	}
+
+	// Tier B: branch arms, as in the spiral
+	distributeBranchArms(tierB, centers, cfg)
+
+	// Tier C: evenly spaced along the bar, scattered across its width
+	for i, tierInfo := range tierC {
+		x := -g.BarLength + (float64(i)+0.5)*2*g.BarLength/float64(len(tierC))
+		y := (domainHash(tierInfo.Software+"_bar_y") - 0.5) * 2 * g.BarWidth
+		z := (domainHash(tierInfo.Software+"_bar_z") - 0.5) * g.BarWidth
+		centers[tierInfo.Software] = Position{X: x, Y: y, Z: z}
+	}
+
+	return centers
+}
+
+// calculateBarredDust puts a quarter of the dust in the bar and distributes
+// the rest like the spiral's
+func calculateBarredDust(instance *Instance, cfg Config) (*Position, string) {
+	if domainHash(instance.Domain+"_bar") >= 0.25 {
+		return calculateOuterRimPosition(instance, cfg)
+	}
+	g := cfg.Galaxy
+	return &Position{
+		X: (domainHash(instance.Domain+"_bar_x")*2 - 1) * g.BarLength,
+		Y: hashGaussian(instance.Domain+"_bar_y") * g.BarWidth,
+		Z: hashGaussian(instance.Domain+"_bar_z") * g.BarWidth / 2,
+	}, "bar"
+}
+
+// ============================================================================
+// Elliptical
+// ============================================================================
+
+// ellipticalLayout scatters systems through a triaxial Gaussian, with the
+// larger tiers concentrated toward the core
+type ellipticalLayout struct{}
+
+func (ellipticalLayout) Name() string { return "elliptical" }
+
+func (ellipticalLayout) Description() string {
+	return "Elliptical: systems in a triaxial Gaussian cloud, largest near the core"
+}
+
+func (ellipticalLayout) Place(instances []Instance, cfg Config) []Instance {
+	return placeGalaxy(instances, cfg, calculateEllipticalCenters, calculateEllipticalDust)
+}
+
+// ellipticalTierScale shrinks the Gaussian for larger tiers
+var ellipticalTierScale = map[string]float64{"A": 0.5, "B": 0.8, "C": 1.0}
+
+func calculateEllipticalCenters(softwareTiers map[string]TierInfo, cfg Config) map[string]Position {
+	centers := make(map[string]Position)
+	for software, tierInfo := range softwareTiers {
+		p := ellipticalPoint(software+"_elliptical", ellipticalTierScale[tierInfo.Tier], cfg)
+		centers[software] = *p
+	}
+	return centers
+}
+
+// calculateEllipticalDust spreads dust through a Gaussian half again as wide
+// as the systems'
+func calculateEllipticalDust(instance *Instance, cfg Config) (*Position, string) {
+	return ellipticalPoint(instance.Domain+"_elliptical", 1.5, cfg), "elliptical"
+}
+
+// ellipticalPoint samples the configured triaxial Gaussian, scaled by scale
+func ellipticalPoint(seed string, scale float64, cfg Config) *Position {
+	g := cfg.Galaxy
+	return &Position{
+		X: hashGaussian(seed+"_x") * g.EllipticalSigmaX * scale,
+		Y: hashGaussian(seed+"_y") * g.EllipticalSigmaY * scale,
+		Z: hashGaussian(seed+"_z") * g.EllipticalSigmaZ * scale,
+	}
+}
+
+// ============================================================================
+// Ring
+// ============================================================================
+
+// ringLayout puts Tier A and B systems on a ring around a Tier C nucleus
+type ringLayout struct{}
+
+func (ringLayout) Name() string { return "ring" }
+
+func (ringLayout) Description() string {
+	return "Ring: large systems on a ring around a nucleus of small ones"
+}
+
+func (ringLayout) Place(instances []Instance, cfg Config) []Instance {
+	return placeGalaxy(instances, cfg, calculateRingCenters, calculateRingDust)
+}
+
+func calculateRingCenters(softwareTiers map[string]TierInfo, cfg Config) map[string]Position {
+	centers := make(map[string]Position)
+	g := cfg.Galaxy
+	tierA, tierB, tierC := splitTiers(softwareTiers)
+
+	// Tier A: evenly spaced on the ring itself
+	for i, tierInfo := range tierA {
+		angle := 2 * math.Pi * float64(i) / float64(len(tierA))
+		z := (domainHash(tierInfo.Software+"_z") - 0.5) * g.RingWidth / 2
+		centers[tierInfo.Software] = Position{
+			X: g.RingRadius * math.Cos(angle),
+			Y: g.RingRadius * math.Sin(angle),
+			Z: z,
+		}
+	}
+
+	// Tier B: in the gaps between Tier A, spread across the ring's width
+	for i, tierInfo := range tierB {
+		angle := 2 * math.Pi * (float64(i) + 0.5) / float64(len(tierB))
+		radius := g.RingRadius + (domainHash(tierInfo.Software+"_ring")-0.5)*2*g.RingWidth
+		z := (domainHash(tierInfo.Software+"_z") - 0.5) * g.RingWidth
+		centers[tierInfo.Software] = Position{
+			X: radius * math.Cos(angle),
+			Y: radius * math.Sin(angle),
+			Z: z,
+		}
+	}
+
+	// Tier C: the nucleus, as the spiral's central bulge
+	distributeCentralBulge(tierC, centers, cfg)
+
+	return centers
+}
+
+// calculateRingDust puts most dust in the ring and the rest in the outer halo
+func calculateRingDust(instance *Instance, cfg Config) (*Position, string) {
+	hash := domainHash(instance.Domain)
+	if domainHash(instance.Domain+"_strategy") >= 0.85 {
+		return calculateOuterHalo(instance, hash, cfg), "halo"
+	}
+	g := cfg.Galaxy
+	angle := hash * 2 * math.Pi
+	radius := g.RingRadius + hashGaussian(instance.Domain+"_ring")*g.RingWidth
+	return &Position{
+		X: radius * math.Cos(angle),
+		Y: radius * math.Sin(angle),
+		Z: hashGaussian(instance.Domain+"_ring_z") * g.RingWidth / 2,
+	}, "ring"
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Morphology Registry Tests
+// ============================================================
+
+// morphologyFixture has Tier A, B and C software plus unknown dust
+func morphologyFixture(cfg Config) []Instance {
+	var instances []Instance
+	add := func(software string, n int) {
+		for i := 0; i < n; i++ {
+			inst := Instance{
+				Domain: fmt.Sprintf("%s%d.example", strings.ToLower(software), i),
+				Stats:  &Stats{UserCount: 10 + i},
+			}
+			if software != "" {
+				inst.Software = &Software{Name: software}
+			}
+			instances = append(instances, inst)
+		}
+	}
+	add("Alpha", cfg.TierAInstanceCount)
+	add("Beta", cfg.TierAInstanceCount)
+	add("Gamma", cfg.TierBInstanceCount)
+	add("Delta", 2)
+	add("Epsilon", 1)
+	add("", 50)
+	return append(instances, layoutFixture()...)
+}
+
+func TestMorphologies_Registered(t *testing.T) {
+	for _, name := range []string{"barred", "elliptical", "ring"} {
+		l, err := findLayout(name)
+		if err != nil || l.Name() != name {
+			t.Errorf("Expected %s layout to be registered, got %v, %v", name, l, err)
+		}
+	}
+}
+
+func TestMorphologies_SharePipeline(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	instances := morphologyFixture(cfg)
+	spiral := spiralLayout{}.Place(instances, cfg)
+
+	for _, l := range []Layout{barredLayout{}, ellipticalLayout{}, ringLayout{}} {
+		result := l.Place(instances, cfg)
+		if !reflect.DeepEqual(result, l.Place(instances, cfg)) {
+			t.Errorf("%s: repeated runs differ", l.Name())
+		}
+		for i := range result {
+			inst := &result[i]
+			if inst.Position == nil || math.IsNaN(inst.Position.X+inst.Position.Y+inst.Position.Z) {
+				t.Fatalf("%s: %s has no valid position", l.Name(), inst.Domain)
+			}
+			// Classification is shared with the spiral; only placement differs
+			if inst.PositionType != spiral[i].PositionType {
+				t.Errorf("%s: %s typed %s, spiral says %s", l.Name(), inst.Domain, inst.PositionType, spiral[i].PositionType)
+			}
+			if inst.PositionType == "supergiant" && *inst.Position != *spiral[i].Position {
+				t.Errorf("%s: supergiant %s moved to %+v", l.Name(), inst.Domain, inst.Position)
+			}
+		}
+	}
+}
+
+// ============================================================
+// B. Shape Tests
+// ============================================================
+
+func morphologyTiers() map[string]TierInfo {
+	return map[string]TierInfo{
+		"A1": {Tier: "A", InstanceCount: 900, Software: "A1"},
+		"A2": {Tier: "A", InstanceCount: 800, Software: "A2"},
+		"A3": {Tier: "A", InstanceCount: 700, Software: "A3"},
+		"B1": {Tier: "B", InstanceCount: 60, Software: "B1"},
+		"B2": {Tier: "B", InstanceCount: 50, Software: "B2"},
+		"C1": {Tier: "C", InstanceCount: 3, Software: "C1"},
+		"C2": {Tier: "C", InstanceCount: 2, Software: "C2"},
+		"C3": {Tier: "C", InstanceCount: 1, Software: "C3"},
+	}
+}
+
+func TestBarredCenters_BarFeedsArms(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Galaxy.Arms = 2
+	g := cfg.Galaxy
+	centers := calculateBarredCenters(morphologyTiers(), cfg)
+
+	// The first system on each arm sits at a bar end
+	if p := centers["A1"]; math.Abs(p.X-g.BarLength) > 1e-6 || math.Abs(p.Y) > 1e-6 {
+		t.Errorf("A1 should start at the +x bar end, got %+v", p)
+	}
+	if p := centers["A2"]; math.Abs(p.X+g.BarLength) > 1e-6 || math.Abs(p.Y) > 1e-6 {
+		t.Errorf("A2 should start at the -x bar end, got %+v", p)
+	}
+	for _, sw := range []string{"C1", "C2", "C3"} {
+		p := centers[sw]
+		if math.Abs(p.X) > g.BarLength || math.Abs(p.Y) > g.BarWidth {
+			t.Errorf("%s should lie on the bar, got %+v", sw, p)
+		}
+	}
+}
+
+func TestEllipticalCenters_LargerTiersNearCore(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	tiers := map[string]TierInfo{}
+	for i := 0; i < 200; i++ {
+		for _, tier := range []string{"A", "C"} {
+			sw := fmt.Sprintf("%s%d", tier, i)
+			tiers[sw] = TierInfo{Tier: tier, Software: sw}
+		}
+	}
+	centers := calculateEllipticalCenters(tiers, cfg)
+
+	spread := func(tier string) (x, z float64) {
+		for sw, p := range centers {
+			if strings.HasPrefix(sw, tier) {
+				x += p.X * p.X
+				z += p.Z * p.Z
+			}
+		}
+		return math.Sqrt(x / 200), math.Sqrt(z / 200)
+	}
+	ax, _ := spread("A")
+	cx, cz := spread("C")
+	if ax >= cx {
+		t.Errorf("Tier A spread %.0f should be below Tier C spread %.0f", ax, cx)
+	}
+	// Triaxial: the x axis is wider than the z axis
+	if cz >= cx {
+		t.Errorf("Expected sigma_x > sigma_z, measured %.0f vs %.0f", cx, cz)
+	}
+	if math.Abs(cx-cfg.Galaxy.EllipticalSigmaX)/cfg.Galaxy.EllipticalSigmaX > 0.2 {
+		t.Errorf("Tier C x spread %.0f far from elliptical_sigma_x %.0f", cx, cfg.Galaxy.EllipticalSigmaX)
+	}
+}
+
+func TestRingCenters_OnTheRing(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	g := cfg.Galaxy
+	centers := calculateRingCenters(morphologyTiers(), cfg)
+
+	for sw, p := range centers {
+		r := math.Hypot(p.X, p.Y)
+		switch sw[0] {
+		case 'A':
+			if math.Abs(r-g.RingRadius) > 1e-6 {
+				t.Errorf("%s at radius %.0f, want ring_radius %.0f", sw, r, g.RingRadius)
+			}
+		case 'B':
+			if math.Abs(r-g.RingRadius) > g.RingWidth {
+				t.Errorf("%s at radius %.0f, want within ring_width of the ring", sw, r)
+			}
+		case 'C':
+			if r >= g.RingRadius/2 {
+				t.Errorf("%s at radius %.0f should be in the nucleus", sw, r)
+			}
+		}
+	}
+}
+
+func TestValidate_Morphologies(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Galaxy.RingRadius = 0
+	cfg.Galaxy.EllipticalSigmaZ = -1
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "galaxy.ring_radius") || !strings.Contains(err.Error(), "galaxy.elliptical_sigma_z") {
+		t.Errorf("Expected ring_radius and elliptical_sigma_z problems, got %v", err)
+	}
+}
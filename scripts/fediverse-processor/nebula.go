@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ============================================================================
+// Nebula Clustering
+// ============================================================================
+
+// Nebula key policies decide which unknown-software instances share a nebula
+const (
+	NebulaKeyRegistrableDomain = "registrable_domain" // eTLD+1: a.example.co.uk and b.example.co.uk
+	NebulaKeyTLD               = "tld"                // Last label: every .de instance
+	NebulaKeyProvider          = "provider"           // Hosting platform suffix (fly.dev, herokuapp.com, ...)
+	NebulaKeyMapping           = "mapping"            // Groups from the dust.nebula_map file
+	NebulaKeyPrefix            = "prefix"             // First six characters of the domain (legacy)
+)
+
+var nebulaKeyPolicies = []string{
+	NebulaKeyRegistrableDomain,
+	NebulaKeyTLD,
+	NebulaKeyProvider,
+	NebulaKeyMapping,
+	NebulaKeyPrefix,
+}
+
+// nebulaKey returns the cluster an instance's nebula is named and placed by.
+// Domains the policy cannot classify (IP addresses, bare labels, unmapped
+// hosts) fall back to their registrable domain or, failing that, themselves.
+func nebulaKey(instance *Instance, cfg Config) string {
+	if cfg.Dust.NebulaKey == NebulaKeyPrefix {
+		if len(instance.Domain) > 6 {
+			return instance.Domain[:6]
+		}
+		return instance.Domain
+	}
+
+	domain := normalizeDomain(instance.Domain)
+	if net.ParseIP(domain) != nil {
+		return domain
+	}
+	switch cfg.Dust.NebulaKey {
+	case NebulaKeyTLD:
+		if i := strings.LastIndexByte(domain, '.'); i >= 0 {
+			return domain[i+1:]
+		}
+	case NebulaKeyProvider:
+		// The private section of the public suffix list names platforms that
+		// hand out subdomains to their customers
+		if suffix, icann := publicsuffix.PublicSuffix(domain); !icann && suffix != domain {
+			return suffix
+		}
+	case NebulaKeyMapping:
+		if instance.NebulaGroup != "" {
+			return instance.NebulaGroup
+		}
+	}
+	return registrableDomain(domain)
+}
+
+// registrableDomain returns the eTLD+1 of domain, or domain itself when it has none
+func registrableDomain(domain string) string {
+	if d, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+		return d
+	}
+	return domain
+}
+
+// NebulaMap assigns domains to named groups. A key covers the domain itself
+// and all of its subdomains.
+type NebulaMap map[string]string
+
+// LoadNebulaMap reads a JSON object mapping domains to group names. The file
+// may be gzip or zstd compressed.
+func LoadNebulaMap(path string) (NebulaMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open nebula map %q: %w", path, err)
+	}
+	defer f.Close()
+
+	r, closeReader, err := decompressReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("nebula map %q: %w", path, err)
+	}
+	defer closeReader()
+
+	var raw map[string]string
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("cannot parse nebula map %q: %w", path, err)
+	}
+	m := make(NebulaMap, len(raw))
+	for domain, group := range raw {
+		m[normalizeDomain(domain)] = group
+	}
+	return m, nil
+}
+
+// lookup returns the group for domain or its closest mapped parent domain
+func (m NebulaMap) lookup(domain string) (string, bool) {
+	for d := normalizeDomain(domain); d != ""; {
+		if group, ok := m[d]; ok {
+			return group, true
+		}
+		i := strings.IndexByte(d, '.')
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+	return "", false
+}
+
+// AttachNebulaMap copies each instance's group from m onto the instance and
+// returns how many instances were mapped
+func AttachNebulaMap(instances []Instance, m NebulaMap) int {
+	matched := 0
+	for i := range instances {
+		if group, ok := m.lookup(instances[i].Domain); ok {
+			instances[i].NebulaGroup = group
+			matched++
+		}
+	}
+	return matched
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Nebula Key Tests
+// ============================================================
+
+func TestNebulaKey_Policies(t *testing.T) {
+	tests := []struct {
+		policy string
+		domain string
+		want   string
+	}{
+		{NebulaKeyRegistrableDomain, "social.example.co.uk", "example.co.uk"},
+		{NebulaKeyRegistrableDomain, "Mastodon.Example.ORG.", "example.org"},
+		{NebulaKeyRegistrableDomain, "192.168.1.10", "192.168.1.10"},
+		{NebulaKeyTLD, "social.example.de", "de"},
+		{NebulaKeyTLD, "localhost", "localhost"},
+		{NebulaKeyProvider, "myinstance.fly.dev", "fly.dev"},
+		{NebulaKeyProvider, "someapp.herokuapp.com", "herokuapp.com"},
+		{NebulaKeyProvider, "social.example.com", "example.com"},
+		{NebulaKeyPrefix, "social.example.com", "social"},
+		{NebulaKeyPrefix, "a.io", "a.io"},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig.clone()
+		cfg.Dust.NebulaKey = tt.policy
+		if got := nebulaKey(&Instance{Domain: tt.domain}, cfg); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.policy, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestNebulaKey_UnrelatedDomainsSeparated(t *testing.T) {
+	a := &Instance{Domain: "social.example"}
+	b := &Instance{Domain: "social.other"}
+
+	cfg := DefaultConfig.clone()
+	if nebulaKey(a, cfg) == nebulaKey(b, cfg) {
+		t.Error("Unrelated domains should not share a nebula by default")
+	}
+	if calculateClusteredNebula(a, nebulaKey(a, cfg), cfg) == nil {
+		t.Fatal("Expected a nebula position")
+	}
+
+	// The legacy policy still groups them by their shared prefix
+	cfg.Dust.NebulaKey = NebulaKeyPrefix
+	if nebulaKey(a, cfg) != nebulaKey(b, cfg) {
+		t.Error("prefix policy should group domains sharing six characters")
+	}
+}
+
+func TestOuterRimPosition_NamesNebula(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Dust.Weights = map[string]float64{"nebula": 1}
+	inst := &Instance{Domain: "a.example.net"}
+	if _, strategy := calculateOuterRimPosition(inst, cfg); strategy != "nebula" {
+		t.Fatalf("Expected nebula strategy, got %s", strategy)
+	}
+	if inst.Nebula != "example.net" {
+		t.Errorf("Expected nebula example.net, got %q", inst.Nebula)
+	}
+
+	// Instances of one nebula share a center, so they land close together
+	other := &Instance{Domain: "b.example.net"}
+	p1, _ := calculateOuterRimPosition(inst, cfg)
+	p2, _ := calculateOuterRimPosition(other, cfg)
+	if d := distance(p1, p2); d > 2*cfg.Dust.NebulaSize+1 {
+		t.Errorf("Same-nebula instances are %.0f apart, want within %g", d, 2*cfg.Dust.NebulaSize)
+	}
+}
+
+// ============================================================
+// B. Nebula Map Tests
+// ============================================================
+
+func writeNebulaMap(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nebulae.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNebulaMap_LookupParents(t *testing.T) {
+	m, err := LoadNebulaMap(writeNebulaMap(t, `{"Uni-Example.EDU": "Universities", "lab.example.org": "Labs"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	instances := []Instance{
+		{Domain: "uni-example.edu"},
+		{Domain: "social.cs.uni-example.edu"},
+		{Domain: "lab.example.org"},
+		{Domain: "example.org"},
+	}
+	if n := AttachNebulaMap(instances, m); n != 3 {
+		t.Errorf("Expected 3 mapped instances, got %d", n)
+	}
+
+	cfg := DefaultConfig.clone()
+	cfg.Dust.NebulaKey = NebulaKeyMapping
+	want := []string{"Universities", "Universities", "Labs", "example.org"}
+	for i := range instances {
+		if got := nebulaKey(&instances[i], cfg); got != want[i] {
+			t.Errorf("%s: nebula %q, want %q", instances[i].Domain, got, want[i])
+		}
+	}
+}
+
+func TestNebulaMap_Errors(t *testing.T) {
+	if _, err := LoadNebulaMap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing nebula map")
+	}
+	_, err := LoadNebulaMap(writeNebulaMap(t, `["not", "a", "map"]`))
+	if err == nil || !strings.Contains(err.Error(), "nebula map") {
+		t.Errorf("Expected a nebula map parse error, got %v", err)
+	}
+}
+
+func TestValidate_NebulaKey(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Dust.NebulaKey = "country"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dust.nebula_key") {
+		t.Errorf("Expected dust.nebula_key error, got %v", err)
+	}
+
+	cfg.Dust.NebulaKey = NebulaKeyMapping
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dust.nebula_map") {
+		t.Errorf("Expected dust.nebula_map error, got %v", err)
+	}
+	cfg.Dust.NebulaMap = "nebulae.json"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// NodeInfo Documents
+// ============================================================================
+
+// Supported NodeInfo schema versions, most preferred first
+var nodeInfoSchemas = []string{
+	"http://nodeinfo.diaspora.software/ns/schema/2.1",
+	"http://nodeinfo.diaspora.software/ns/schema/2.0",
+}
+
+// maxNodeInfoSize caps how much of a response body the crawler will read
+const maxNodeInfoSize = 1 << 20
+
+// nodeInfoDiscovery is the /.well-known/nodeinfo document
+type nodeInfoDiscovery struct {
+	Links []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+// NodeInfo holds the fields of a NodeInfo 2.0/2.1 document the processor uses.
+// Pointers distinguish "not reported" from zero.
+type NodeInfo struct {
+	Version  string `json:"version"`
+	Software struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"software"`
+	Usage struct {
+		Users struct {
+			Total       *int `json:"total"`
+			ActiveMonth *int `json:"activeMonth"`
+		} `json:"users"`
+	} `json:"usage"`
+	OpenRegistrations *bool `json:"openRegistrations"`
+}
+
+// ============================================================================
+// Crawler
+// ============================================================================
+
+// NodeInfoCrawler resolves and fetches NodeInfo documents for many hosts
+type NodeInfoCrawler struct {
+	HTTP        *http.Client
+	Scheme      string        // "https" in production; tests use "http"
+	Concurrency int           // Maximum hosts crawled at once
+	HostTimeout time.Duration // Budget for discovery plus document fetch per host
+}
+
+// NewNodeInfoCrawler returns a crawler with production defaults
+func NewNodeInfoCrawler() *NodeInfoCrawler {
+	return &NodeInfoCrawler{
+		HTTP:        &http.Client{Timeout: 30 * time.Second, CheckRedirect: sameHostRedirect},
+		Scheme:      "https",
+		Concurrency: 16,
+		HostTimeout: 10 * time.Second,
+	}
+}
+
+// CrawlResult is the outcome of crawling one instance
+type CrawlResult struct {
+	Domain   string
+	NodeInfo *NodeInfo
+	Err      error
+}
+
+// Crawl fetches NodeInfo for every instance. Results are returned in input
+// order regardless of which hosts answer first.
+func (c *NodeInfoCrawler) Crawl(ctx context.Context, instances []Instance) []CrawlResult {
+	results := make([]CrawlResult, len(instances))
+	workers := c.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				domain := instances[i].Domain
+				info, err := c.crawlHost(ctx, domain)
+				results[i] = CrawlResult{Domain: domain, NodeInfo: info, Err: err}
+			}
+		}()
+	}
+
+	for i := range instances {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i] = CrawlResult{Domain: instances[i].Domain, Err: ctx.Err()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// crawlHost resolves /.well-known/nodeinfo on domain and fetches the best
+// supported schema it links to
+func (c *NodeInfoCrawler) crawlHost(ctx context.Context, domain string) (*NodeInfo, error) {
+	if c.HostTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.HostTimeout)
+		defer cancel()
+	}
+
+	var discovery nodeInfoDiscovery
+	discoveryURL := c.Scheme + "://" + domain + "/.well-known/nodeinfo"
+	if err := c.getJSON(ctx, discoveryURL, &discovery); err != nil {
+		return nil, err
+	}
+
+	href := ""
+	for _, schema := range nodeInfoSchemas {
+		for _, link := range discovery.Links {
+			if link.Rel == schema {
+				href = link.Href
+				break
+			}
+		}
+		if href != "" {
+			break
+		}
+	}
+	if href == "" {
+		return nil, fmt.Errorf("no NodeInfo 2.0/2.1 link in discovery document")
+	}
+	docURL, err := nodeInfoDocumentURL(discoveryURL, domain, href)
+	if err != nil {
+		return nil, err
+	}
+
+	var info NodeInfo
+	if err := c.getJSON(ctx, docURL, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// nodeInfoDocumentURL resolves a discovery link against the discovery URL and
+// rejects links that leave the instance: the discovery document is untrusted,
+// so following it to other hosts or schemes would let any instance point the
+// crawler at arbitrary URLs.
+func nodeInfoDocumentURL(discoveryURL, domain, href string) (string, error) {
+	base, err := url.Parse(discoveryURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid NodeInfo link %q: %w", href, err)
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("NodeInfo link %q must use http or https", href)
+	}
+	if !onHost(u, domain) {
+		return "", fmt.Errorf("NodeInfo link %q points away from %s", href, domain)
+	}
+	return u.String(), nil
+}
+
+// sameHostRedirect is the crawler's http.Client CheckRedirect: a redirect is
+// as untrusted as a discovery link, so it may not leave the host either
+func sameHostRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after %d redirects", len(via))
+	}
+	if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+		return fmt.Errorf("redirect to %s must use http or https", req.URL)
+	}
+	if domain := via[0].URL.Host; !onHost(req.URL, domain) {
+		return fmt.Errorf("redirect to %s points away from %s", req.URL, domain)
+	}
+	return nil
+}
+
+// onHost reports whether u is on domain, with or without a port
+func onHost(u *url.URL, domain string) bool {
+	return strings.EqualFold(u.Host, domain) || strings.EqualFold(u.Hostname(), domain)
+}
+
+func (c *NodeInfoCrawler) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "fediverse-processor (+https://github.com/r0k1s-i/fediverse-with-100k-stars)")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxNodeInfoSize)).Decode(v); err != nil {
+		return fmt.Errorf("GET %s: cannot parse response: %w", url, err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Enrichment and Verification
+// ============================================================================
+
+// Discrepancy is a field where the input disagrees with the instance's own NodeInfo
+type Discrepancy struct {
+	Domain   string `json:"domain"`
+	Field    string `json:"field"`
+	Input    string `json:"input"`
+	NodeInfo string `json:"nodeinfo"`
+}
+
+// CrawlFailure records a host whose NodeInfo could not be retrieved
+type CrawlFailure struct {
+	Domain string `json:"domain"`
+	Error  string `json:"error"`
+}
+
+// CrawlReport summarizes a crawl
+type CrawlReport struct {
+	Crawled       int            `json:"crawled"`
+	Succeeded     int            `json:"succeeded"`
+	Failures      []CrawlFailure `json:"failures"`
+	Discrepancies []Discrepancy  `json:"discrepancies"`
+}
+
+// ApplyNodeInfo compares instances with their crawl results and reports every
+// discrepancy. If enrich is true the NodeInfo values replace the input values;
+// otherwise the instances are returned unchanged.
+func ApplyNodeInfo(instances []Instance, results []CrawlResult, enrich bool) ([]Instance, CrawlReport) {
+	report := CrawlReport{
+		Crawled:       len(results),
+		Failures:      []CrawlFailure{},
+		Discrepancies: []Discrepancy{},
+	}
+	out := make([]Instance, len(instances))
+	copy(out, instances)
+	names := softwareNameIndex(instances)
+
+	for i, res := range results {
+		if res.Err != nil {
+			report.Failures = append(report.Failures, CrawlFailure{Domain: res.Domain, Error: res.Err.Error()})
+			continue
+		}
+		report.Succeeded++
+		inst := &out[i]
+		info := res.NodeInfo
+		differ := func(field, input, reported string) {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Domain: inst.Domain, Field: field, Input: input, NodeInfo: reported,
+			})
+		}
+
+		// Software name and version
+		sw := Software{}
+		if inst.Software != nil {
+			sw = *inst.Software
+		}
+		updatedSw := sw
+		if name := info.Software.Name; name != "" && !strings.EqualFold(sw.Name, name) {
+			differ("software.name", sw.Name, name)
+			updatedSw.Name = canonicalSoftwareName(name, names)
+		}
+		if version := info.Software.Version; version != "" && version != sw.Version {
+			differ("software.version", sw.Version, version)
+			updatedSw.Version = version
+		}
+		if enrich && updatedSw != sw {
+			inst.Software = &updatedSw
+		}
+
+		// Usage
+		current := Stats{}
+		if inst.Stats != nil {
+			current = *inst.Stats
+		}
+		updated := current
+		if total := info.Usage.Users.Total; total != nil && *total != current.UserCount {
+			differ("stats.user_count", strconv.Itoa(current.UserCount), strconv.Itoa(*total))
+			updated.UserCount = *total
+		}
+		if active := info.Usage.Users.ActiveMonth; active != nil && *active != current.MonthlyActiveUsers {
+			differ("stats.monthly_active_users", strconv.Itoa(current.MonthlyActiveUsers), strconv.Itoa(*active))
+			updated.MonthlyActiveUsers = *active
+		}
+		if enrich && updated != current {
+			inst.Stats = &updated
+		}
+
+		// Registrations
+		if open := info.OpenRegistrations; open != nil {
+			if inst.OpenRegistrations == nil || *inst.OpenRegistrations != *open {
+				input := ""
+				if inst.OpenRegistrations != nil {
+					input = strconv.FormatBool(*inst.OpenRegistrations)
+				}
+				differ("open_registrations", input, strconv.FormatBool(*open))
+				if enrich {
+					v := *open
+					inst.OpenRegistrations = &v
+				}
+			}
+		}
+	}
+	return out, report
+}
+
+// knownSoftwareNames maps NodeInfo's lowercase software identifiers to the
+// names FediDB uses, for software whose name is not simply capitalized
+var knownSoftwareNames = map[string]string{
+	"bookwyrm":    "BookWyrm",
+	"gotosocial":  "GoToSocial",
+	"peertube":    "PeerTube",
+	"writefreely": "WriteFreely",
+}
+
+// softwareNameIndex maps each lowercased software name in instances to its
+// most common spelling there (ties go to the alphabetically first)
+func softwareNameIndex(instances []Instance) map[string]string {
+	counts := make(map[string]map[string]int)
+	for i := range instances {
+		if sw := instances[i].Software; sw != nil && sw.Name != "" {
+			key := strings.ToLower(sw.Name)
+			if counts[key] == nil {
+				counts[key] = make(map[string]int)
+			}
+			counts[key][sw.Name]++
+		}
+	}
+	index := make(map[string]string, len(counts))
+	for key, spellings := range counts {
+		best := ""
+		for name, n := range spellings {
+			if best == "" || n > spellings[best] || (n == spellings[best] && name < best) {
+				best = name
+			}
+		}
+		index[key] = best
+	}
+	return index
+}
+
+// canonicalSoftwareName converts NodeInfo's lowercase software identifiers
+// (e.g. "gotosocial") to the form used by FediDB and the layout: the spelling
+// already in the dataset, else a known name, else the name capitalized
+func canonicalSoftwareName(name string, dataset map[string]string) string {
+	if name == "" {
+		return name
+	}
+	key := strings.ToLower(name)
+	if known, ok := dataset[key]; ok {
+		return known
+	}
+	if known, ok := knownSoftwareNames[key]; ok {
+		return known
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// ============================================================================
+// Subcommand
+// ============================================================================
+
+// runCrawl implements the `crawl` subcommand
+func runCrawl(args []string) int {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	input := fs.String("input", filepath.Join("..", "..", "data", "fediverse_raw.json"), "Input JSON file (use '-' for stdin)")
+	output := fs.String("output", "", "Write enriched instances here (omit to only verify)")
+	reportFile := fs.String("report", "", "Write the discrepancy report as JSON to this file")
+	concurrency := fs.Int("concurrency", 16, "Maximum hosts crawled at once")
+	timeout := fs.Duration("timeout", 10*time.Second, "Per-host timeout")
+	verbose := fs.Bool("verbose", false, "Print progress information")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE:\n  fediverse-processor crawl [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Fetches /.well-known/nodeinfo for every instance and reports where the input\n")
+		fmt.Fprintf(os.Stderr, "disagrees. With -output, NodeInfo values replace the input values.\n\nOPTIONS:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *verbose {
+		os.Setenv("VERBOSE", "1")
+	}
+
+	instances, err := ReadInstances(*input, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load input: %v\n", err)
+		return 1
+	}
+
+	crawler := NewNodeInfoCrawler()
+	crawler.Concurrency = *concurrency
+	crawler.HostTimeout = *timeout
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "🛰️  Crawling NodeInfo for %d instances (concurrency %d)...\n", len(instances), *concurrency)
+	}
+	results := crawler.Crawl(ctx, instances)
+	enriched, report := ApplyNodeInfo(instances, results, *output != "")
+
+	if *output != "" {
+		if err := WriteInstances(*output, "", "", enriched); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to save output: %v\n", err)
+			return 1
+		}
+	}
+	if *reportFile != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*reportFile, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to save report: %v\n", err)
+			return 1
+		}
+	}
+
+	byField := make(map[string]int)
+	for _, d := range report.Discrepancies {
+		byField[d.Field]++
+	}
+	fmt.Fprintf(os.Stderr, "🛰️  Crawled %d hosts: %d ok, %d failed\n", report.Crawled, report.Succeeded, len(report.Failures))
+	for _, field := range []string{"software.name", "software.version", "stats.user_count", "stats.monthly_active_users", "open_registrations"} {
+		if n := byField[field]; n > 0 {
+			fmt.Fprintf(os.Stderr, "  %-28s %5d discrepancies\n", field, n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================
+// Test Fixtures: stand-in fleet of fediverse hosts
+// ============================================================
+
+// nodeInfoHost starts one stand-in host serving the given NodeInfo document
+// (or misbehaving, depending on mode) and returns its host:port domain.
+func nodeInfoHost(t *testing.T, mode, doc string, inFlight, peak *int32) string {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inFlight != nil {
+			n := atomic.AddInt32(inFlight, 1)
+			defer atomic.AddInt32(inFlight, -1)
+			for {
+				p := atomic.LoadInt32(peak)
+				if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		switch {
+		case mode == "slow":
+			time.Sleep(500 * time.Millisecond)
+			return
+		case mode == "404":
+			http.NotFound(w, r)
+		case r.URL.Path == "/.well-known/nodeinfo":
+			fmt.Fprintf(w, `{"links":[
+				{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"%[1]s/nodeinfo/2.0"},
+				{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.1","href":"%[1]s/nodeinfo/2.1"}
+			]}`, srv.URL)
+		case r.URL.Path == "/nodeinfo/2.1":
+			fmt.Fprint(w, doc)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func testCrawler() *NodeInfoCrawler {
+	c := NewNodeInfoCrawler()
+	c.Scheme = "http"
+	c.HostTimeout = 200 * time.Millisecond
+	return c
+}
+
+// ============================================================
+// A. Crawl Tests
+// ============================================================
+
+func TestCrawl_ParsesNodeInfo21(t *testing.T) {
+	domain := nodeInfoHost(t, "ok", `{"version":"2.1",
+		"software":{"name":"mastodon","version":"4.3.1"},
+		"usage":{"users":{"total":1200,"activeMonth":300}},
+		"openRegistrations":true}`, nil, nil)
+
+	results := testCrawler().Crawl(context.Background(), []Instance{{Domain: domain}})
+
+	if results[0].Err != nil {
+		t.Fatalf("Crawl failed: %v", results[0].Err)
+	}
+	info := results[0].NodeInfo
+	if info.Software.Name != "mastodon" || info.Software.Version != "4.3.1" {
+		t.Errorf("Software not parsed: %+v", info.Software)
+	}
+	if *info.Usage.Users.Total != 1200 || *info.Usage.Users.ActiveMonth != 300 || !*info.OpenRegistrations {
+		t.Errorf("Usage not parsed: %+v", info.Usage)
+	}
+}
+
+func TestCrawl_FailuresAndTimeouts(t *testing.T) {
+	instances := []Instance{
+		{Domain: nodeInfoHost(t, "404", "", nil, nil)},
+		{Domain: nodeInfoHost(t, "slow", "", nil, nil)},
+		{Domain: nodeInfoHost(t, "ok", `{"software":{"name":"pleroma"}}`, nil, nil)},
+	}
+
+	start := time.Now()
+	results := testCrawler().Crawl(context.Background(), instances)
+
+	if results[0].Err == nil || results[1].Err == nil {
+		t.Errorf("Expected 404 and timeout failures, got %v / %v", results[0].Err, results[1].Err)
+	}
+	if results[2].Err != nil {
+		t.Errorf("Healthy host should succeed: %v", results[2].Err)
+	}
+	if elapsed := time.Since(start); elapsed > 450*time.Millisecond {
+		t.Errorf("Per-host timeout not enforced, crawl took %v", elapsed)
+	}
+}
+
+func TestCrawl_RespectsConcurrencyLimit(t *testing.T) {
+	var inFlight, peak int32
+	var instances []Instance
+	for i := 0; i < 12; i++ {
+		instances = append(instances, Instance{Domain: nodeInfoHost(t, "ok", `{}`, &inFlight, &peak)})
+	}
+
+	crawler := testCrawler()
+	crawler.Concurrency = 3
+	results := crawler.Crawl(context.Background(), instances)
+
+	for i, res := range results {
+		if res.Domain != instances[i].Domain {
+			t.Errorf("Result %d out of order: %s", i, res.Domain)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Concurrency limit 3 exceeded: peak %d hosts in flight", peak)
+	}
+}
+
+// ============================================================
+// B. Enrichment and Discrepancy Tests
+// ============================================================
+
+func TestApplyNodeInfo_ReportsAndEnriches(t *testing.T) {
+	total, active, open := 1500, 400, false
+	info := &NodeInfo{}
+	info.Software.Name = "misskey"
+	info.Software.Version = "2024.10.1"
+	info.Usage.Users.Total = &total
+	info.Usage.Users.ActiveMonth = &active
+	info.OpenRegistrations = &open
+
+	instances := []Instance{
+		{Domain: "stale.example", Software: &Software{Name: "Mastodon"}, Stats: &Stats{UserCount: 1000, MonthlyActiveUsers: 400}},
+		{Domain: "down.example"},
+	}
+	results := []CrawlResult{
+		{Domain: "stale.example", NodeInfo: info},
+		{Domain: "down.example", Err: fmt.Errorf("connection refused")},
+	}
+
+	verified, report := ApplyNodeInfo(instances, results, false)
+	if verified[0].Software.Name != "Mastodon" || verified[0].Stats.UserCount != 1000 {
+		t.Error("Verify mode must not modify instances")
+	}
+
+	fields := map[string]bool{}
+	for _, d := range report.Discrepancies {
+		fields[d.Field] = true
+	}
+	for _, want := range []string{"software.name", "software.version", "stats.user_count", "open_registrations"} {
+		if !fields[want] {
+			t.Errorf("Missing %s discrepancy in %+v", want, report.Discrepancies)
+		}
+	}
+	if fields["stats.monthly_active_users"] {
+		t.Error("Matching MAU should not be reported")
+	}
+	if report.Succeeded != 1 || len(report.Failures) != 1 || report.Failures[0].Domain != "down.example" {
+		t.Errorf("Unexpected failure accounting: %+v", report)
+	}
+
+	enriched, _ := ApplyNodeInfo(instances, results, true)
+	got := enriched[0]
+	if got.Software.Name != "Misskey" || got.Software.Version != "2024.10.1" {
+		t.Errorf("Software not enriched: %+v", got.Software)
+	}
+	if got.Stats.UserCount != 1500 || got.OpenRegistrations == nil || *got.OpenRegistrations {
+		t.Errorf("Stats/registrations not enriched: %+v %v", got.Stats, got.OpenRegistrations)
+	}
+	if instances[0].Stats.UserCount != 1000 {
+		t.Error("Enrichment must not mutate the input slice")
+	}
+}
+
+func TestApplyNodeInfo_SoftwareNameCaseInsensitive(t *testing.T) {
+	info := &NodeInfo{}
+	info.Software.Name = "mastodon"
+
+	_, report := ApplyNodeInfo(
+		[]Instance{{Domain: "a.example", Software: &Software{Name: "Mastodon"}}},
+		[]CrawlResult{{Domain: "a.example", NodeInfo: info}},
+		false,
+	)
+	if len(report.Discrepancies) != 0 {
+		t.Errorf("Case-only name difference should not be reported: %+v", report.Discrepancies)
+	}
+}
+
+func TestCanonicalSoftwareName(t *testing.T) {
+	dataset := softwareNameIndex([]Instance{
+		{Software: &Software{Name: "Akkoma"}},
+		{Software: &Software{Name: "akkoma"}},
+		{Software: &Software{Name: "Akkoma"}},
+	})
+	tests := map[string]string{
+		"akkoma":     "Akkoma",     // Spelling already in the dataset
+		"gotosocial": "GoToSocial", // Known FediDB name
+		"peertube":   "PeerTube",
+		"lemmy":      "Lemmy", // Otherwise capitalized
+		"":           "",
+	}
+	for in, want := range tests {
+		if got := canonicalSoftwareName(in, dataset); got != want {
+			t.Errorf("canonicalSoftwareName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// ============================================================
+// C. Discovery Link Tests
+// ============================================================
+
+func TestNodeInfoDocumentURL_StaysOnInstance(t *testing.T) {
+	const discovery = "https://social.example/.well-known/nodeinfo"
+	tests := []struct {
+		href string
+		want string // "" = rejected
+	}{
+		{"https://social.example/nodeinfo/2.1", "https://social.example/nodeinfo/2.1"},
+		{"http://SOCIAL.example/nodeinfo/2.1", "http://SOCIAL.example/nodeinfo/2.1"},
+		{"/nodeinfo/2.0", "https://social.example/nodeinfo/2.0"},
+		{"https://social.example:443/nodeinfo/2.1", "https://social.example:443/nodeinfo/2.1"},
+		{"https://169.254.169.254/latest/meta-data", ""},
+		{"https://social.example.evil.test/nodeinfo", ""},
+		{"file:///etc/passwd", ""},
+		{"gopher://social.example/", ""},
+	}
+	for _, tt := range tests {
+		got, err := nodeInfoDocumentURL(discovery, "social.example", tt.href)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q should be rejected, got %q", tt.href, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q resolved to %q, %v; want %q", tt.href, got, err, tt.want)
+		}
+	}
+}
+
+func TestCrawl_RedirectsStayOnInstance(t *testing.T) {
+	var offHost int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&offHost, 1)
+		fmt.Fprint(w, `{"software":{"name":"spoofed"}}`)
+	}))
+	t.Cleanup(other.Close)
+
+	// One host sends its discovery document elsewhere; the other moves it
+	// to another path on itself
+	away := httptest.NewServer(http.RedirectHandler(other.URL+"/.well-known/nodeinfo", http.StatusFound))
+	t.Cleanup(away.Close)
+	moved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/nodeinfo":
+			http.Redirect(w, r, "/discovery", http.StatusMovedPermanently)
+		case "/discovery":
+			fmt.Fprint(w, `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"/doc"}]}`)
+		default:
+			fmt.Fprint(w, `{"software":{"name":"pleroma"}}`)
+		}
+	}))
+	t.Cleanup(moved.Close)
+
+	results := testCrawler().Crawl(context.Background(), []Instance{
+		{Domain: strings.TrimPrefix(away.URL, "http://")},
+		{Domain: strings.TrimPrefix(moved.URL, "http://")},
+	})
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "points away") {
+		t.Errorf("Expected the off-host redirect to be refused, got %v", results[0].Err)
+	}
+	if n := atomic.LoadInt32(&offHost); n != 0 {
+		t.Errorf("Crawler followed the redirect to another host (%d requests)", n)
+	}
+	if results[1].Err != nil || results[1].NodeInfo.Software.Name != "pleroma" {
+		t.Errorf("Expected the on-host redirect to be followed, got %v", results[1].Err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable generated for every config key,
+// e.g. tier_a_system_radius -> FEDIPROC_TIER_A_SYSTEM_RADIUS
+const EnvPrefix = "FEDIPROC_"
+
+// ConfigSources records where each effective config value came from,
+// keyed by config key (e.g. "tier_a_system_radius" -> "env:FEDIPROC_TIER_A_SYSTEM_RADIUS")
+type ConfigSources map[string]string
+
+// ConfigLayers lists the configuration sources applied in order:
+// defaults < preset < file < env < flags
+type ConfigLayers struct {
+	Preset   string
+	File     string
+	Env      []string // KEY=VALUE pairs, normally os.Environ()
+	Sets     []string // key=value pairs from repeated -set flags
+	AsOf     string   // -as-of flag, shorthand for -set as_of=...
+	Layout   string   // -layout flag, shorthand for -set layout=...
+	Workers  int      // -workers flag, shorthand for -set workers=... (0 = unset)
+	Previous string   // -previous flag, shorthand for -set previous=...
+}
+
+// ResolveConfig builds the effective configuration from DefaultConfig and layers
+func ResolveConfig(layers ConfigLayers) (Config, ConfigSources, error) {
+	cfg := DefaultConfig.clone()
+	sources := make(ConfigSources)
+	for _, key := range configKeyPaths() {
+		sources[key] = "default"
+	}
+
+	if layers.Preset != "" {
+		presetCfg, err := ApplyPreset(layers.Preset, cfg)
+		if err != nil {
+			return cfg, sources, err
+		}
+		for _, d := range diffConfigs(cfg, presetCfg) {
+			sources[d.Key] = "preset:" + layers.Preset
+		}
+		cfg = presetCfg
+	}
+
+	if layers.File != "" {
+		data, err := os.ReadFile(layers.File)
+		if err != nil {
+			return cfg, sources, fmt.Errorf("cannot read config file %q: %w", layers.File, err)
+		}
+		fileCfg, keys, err := parseConfig(layers.File, data, cfg)
+		if err != nil {
+			return cfg, sources, err
+		}
+		for _, key := range keys {
+			sources[key] = "file:" + layers.File
+		}
+		cfg = fileCfg
+	}
+
+	envKeys := make(map[string]string)
+	for _, key := range configKeyPaths() {
+		envKeys[configEnvName(key)] = key
+	}
+	for _, kv := range layers.Env {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		// A stale variable in the shell should not break every run, so
+		// unknown names are only reported; -set stays strict
+		key, ok := envKeys[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "⚠️  Ignoring unknown config environment variable %s\n", name)
+			continue
+		}
+		if err := setConfigValue(&cfg, key, value); err != nil {
+			return cfg, sources, fmt.Errorf("%s: %w", name, err)
+		}
+		sources[key] = "env:" + name
+	}
+
+	for _, kv := range layers.Sets {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return cfg, sources, fmt.Errorf("-set %q: expected key=value", kv)
+		}
+		key = strings.TrimSpace(key)
+		if err := setConfigValue(&cfg, key, value); err != nil {
+			return cfg, sources, fmt.Errorf("-set %s: %w", key, err)
+		}
+		sources[key] = "flag:-set"
+	}
+
+	if layers.AsOf != "" {
+		cfg.AsOf = layers.AsOf
+		sources["as_of"] = "flag:-as-of"
+	}
+	if layers.Layout != "" {
+		cfg.Layout = layers.Layout
+		sources["layout"] = "flag:-layout"
+	}
+	if layers.Workers != 0 {
+		cfg.Workers = layers.Workers
+		sources["workers"] = "flag:-workers"
+	}
+	if layers.Previous != "" {
+		cfg.Previous = layers.Previous
+		sources["previous"] = "flag:-previous"
+	}
+
+	return cfg, sources, nil
+}
+
+// configEnvName returns the environment variable that overrides key
+func configEnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// configKeyPaths lists every overridable config key. Nested sections are
+// flattened with dots; lists and maps are set as a whole.
+func configKeyPaths() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		fields := yamlFields(t)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ft := fields[name].Type; ft.Kind() == reflect.Struct {
+				walk(ft, prefix+name+".")
+			} else {
+				keys = append(keys, prefix+name)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// configField resolves a dotted key path to the addressable field inside cfg
+func configField(cfg *Config, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+		}
+		f, ok := yamlFields(v.Type())[part]
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	return v, nil
+}
+
+// setConfigValue parses raw as a YAML scalar or flow value (so lists can be
+// given as "[a, b]") and stores it in the field named by key. Sections such
+// as "galaxy" are rejected: setting one whole would zero every key it omits.
+func setConfigValue(cfg *Config, key, raw string) error {
+	field, err := configField(cfg, key)
+	if err != nil {
+		return err
+	}
+	if field.Kind() == reflect.Struct {
+		return fmt.Errorf("%s is a config section; set its keys individually (e.g. %s)", key, sectionExample(field.Type(), key))
+	}
+	value := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(raw), value.Interface()); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", raw, key, err)
+	}
+	field.Set(value.Elem())
+	return nil
+}
+
+// sectionExample returns the first key inside a section, for error messages
+func sectionExample(t reflect.Type, section string) string {
+	var names []string
+	for name := range yamlFields(t) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return section + "." + names[0]
+}
+
+// configFieldMap flattens a Config into its key paths and values
+func configFieldMap(cfg Config) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, key := range configKeyPaths() {
+		v, _ := configField(&cfg, key)
+		fields[key] = v.Interface()
+	}
+	return fields
+}
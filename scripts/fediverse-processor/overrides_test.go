@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+)
+
+// ============================================================
+// A. Override Key Generation Tests
+// ============================================================
+
+func TestConfigKeyPaths_CoverEveryField(t *testing.T) {
+	keys := configKeyPaths()
+	fields := configFieldMap(DefaultConfig)
+	if len(keys) != len(fields) {
+		t.Errorf("Got %d keys but %d fields", len(keys), len(fields))
+	}
+	for _, key := range keys {
+		cfg := DefaultConfig.clone()
+		if _, err := configField(&cfg, key); err != nil {
+			t.Errorf("Key %q does not resolve: %v", key, err)
+		}
+	}
+}
+
+func TestConfigEnvName(t *testing.T) {
+	if got := configEnvName("tier_a_system_radius"); got != "FEDIPROC_TIER_A_SYSTEM_RADIUS" {
+		t.Errorf("configEnvName = %q, want FEDIPROC_TIER_A_SYSTEM_RADIUS", got)
+	}
+}
+
+// ============================================================
+// B. Layering and Provenance Tests
+// ============================================================
+
+func TestResolveConfig_LayerOrder(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "supergiant_radius: 2500\nhue_young: 220\ndomain_hash_range: 5\n")
+
+	cfg, sources, err := ResolveConfig(ConfigLayers{
+		Preset: "compact",
+		File:   path,
+		Env: []string{
+			"PATH=/usr/bin",
+			"FEDIPROC_HUE_YOUNG=210",
+			"FEDIPROC_DOMAIN_HASH_RANGE=7",
+		},
+		Sets: []string{"domain_hash_range=9"},
+	})
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		got    float64
+		want   float64
+		source string
+	}{
+		{"hue_old", cfg.HueOld, DefaultConfig.HueOld, "default"},
+		{"tier_a_system_max_radius", cfg.TierASystemMaxRadius, 2500, "preset:compact"},
+		{"supergiant_radius", cfg.SupergiantRadius, 2500, "file:" + path},
+		{"hue_young", cfg.HueYoung, 210, "env:FEDIPROC_HUE_YOUNG"},
+		{"domain_hash_range", cfg.DomainHashRange, 9, "flag:-set"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %f, want %f", tt.key, tt.got, tt.want)
+		}
+		if sources[tt.key] != tt.source {
+			t.Errorf("%s source = %q, want %q", tt.key, sources[tt.key], tt.source)
+		}
+	}
+}
+
+func TestResolveConfig_SetParsesLists(t *testing.T) {
+	cfg, _, err := ResolveConfig(ConfigLayers{Sets: []string{"supergiants=[{domain: a.example}, {domain: b.example, software: B}]"}})
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %v", err)
+	}
+	if len(cfg.Supergiants) != 2 || cfg.Supergiants[1] != (Supergiant{Domain: "b.example", Software: "B"}) {
+		t.Errorf("Supergiants = %v", cfg.Supergiants)
+	}
+}
+
+func TestResolveConfig_UnknownEnvIgnored(t *testing.T) {
+	cfg, sources, err := ResolveConfig(ConfigLayers{Env: []string{"FEDIPROC_NO_SUCH_KEY=1", "FEDIPROC_HUE_YOUNG=210"}})
+	if err != nil {
+		t.Fatalf("A stale environment variable should not abort the run: %v", err)
+	}
+	if cfg.HueYoung != 210 || sources["hue_young"] != "env:FEDIPROC_HUE_YOUNG" {
+		t.Errorf("Known variables should still apply, got %v from %s", cfg.HueYoung, sources["hue_young"])
+	}
+}
+
+func TestResolveConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers ConfigLayers
+	}{
+		{"unknown set key", ConfigLayers{Sets: []string{"no_such_key=1"}}},
+		{"missing equals", ConfigLayers{Sets: []string{"hue_young"}}},
+		{"bad value", ConfigLayers{Sets: []string{"tier_a_instance_count=many"}}},
+		{"whole section", ConfigLayers{Sets: []string{"galaxy={arms: 3}"}}},
+	}
+	for _, tt := range tests {
+		if _, _, err := ResolveConfig(tt.layers); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
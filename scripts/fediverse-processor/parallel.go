@@ -0,0 +1,42 @@
+package main
+
+import (
+	"sync"
+)
+
+// ============================================================================
+// Worker Pool
+// ============================================================================
+
+// parallelFor calls fn(i) for every i in [0, n) using up to workers
+// goroutines. Each goroutine takes a contiguous block of indices, and fn must
+// only write to slots owned by its index, so the result does not depend on
+// scheduling. workers <= 1 runs serially on the calling goroutine.
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += chunk {
+		hi := lo + chunk
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				fn(i)
+			}
+		}(lo, hi)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// ============================================================
+// A. Worker Pool Tests
+// ============================================================
+
+func TestParallelFor_VisitsEveryIndexOnce(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 8, 100} {
+		seen := make([]int, 37)
+		parallelFor(len(seen), workers, func(i int) { seen[i]++ })
+		for i, n := range seen {
+			if n != 1 {
+				t.Fatalf("workers=%d: index %d visited %d times", workers, i, n)
+			}
+		}
+	}
+}
+
+func TestProcessInstances_ParallelMatchesSerial(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.AsOf = "2026-01-01T00:00:00Z"
+	input := syntheticInstances(2000)
+	for i := range input {
+		input[i].FirstSeenAt = fmt.Sprintf("20%02d-%02d-01T00:00:00Z", 17+i%9, 1+i%12)
+	}
+	input = append(input, layoutFixture()...)
+
+	serial, _ := ProcessInstances(append([]Instance(nil), input...), cfg, CLIOptions{})
+	for _, workers := range []int{2, 7, 16} {
+		cfg.Workers = workers
+		for run := 0; run < 3; run++ {
+			parallel, _ := ProcessInstances(append([]Instance(nil), input...), cfg, CLIOptions{})
+			if !reflect.DeepEqual(serial, parallel) {
+				t.Fatalf("workers=%d run %d: output differs from the serial path", workers, run)
+			}
+		}
+	}
+}
+
+func TestWorkersFlag(t *testing.T) {
+	cfg, sources, err := ResolveConfig(ConfigLayers{Workers: 4})
+	if err != nil || cfg.Workers != 4 || sources["workers"] != "flag:-workers" {
+		t.Errorf("-workers: got %d from %q, %v", cfg.Workers, sources["workers"], err)
+	}
+	cfg, _, _ = ResolveConfig(ConfigLayers{})
+	if cfg.Workers != 1 {
+		t.Errorf("Expected serial by default, got %d workers", cfg.Workers)
+	}
+
+	cfg.Workers = -2
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a negative worker count")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PeerGraph maps a normalized domain to the normalized domains it federates
+// with, as reported by Mastodon's /api/v1/instance/peers
+type PeerGraph map[string][]string
+
+// LoadPeerGraph reads peer lists from path, which is either
+//   - a directory of <domain>.json files, each holding one /api/v1/instance/peers
+//     response (a JSON array of domains), or
+//   - a single JSON file mapping each domain to its peer array.
+//
+// Files may be gzip or zstd compressed.
+func LoadPeerGraph(path string) (PeerGraph, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open peer lists: %w", err)
+	}
+
+	graph := make(PeerGraph)
+	if !info.IsDir() {
+		var lists map[string][]string
+		if err := readPeerFile(path, &lists); err != nil {
+			return nil, err
+		}
+		for domain, peers := range lists {
+			graph.add(domain, peers)
+		}
+		return graph, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read peer directory: %w", err)
+	}
+	for _, e := range entries {
+		base, _ := splitCompressionExt(e.Name())
+		if e.IsDir() || !strings.HasSuffix(base, ".json") {
+			continue
+		}
+		var peers []string
+		if err := readPeerFile(filepath.Join(path, e.Name()), &peers); err != nil {
+			return nil, err
+		}
+		graph.add(strings.TrimSuffix(base, ".json"), peers)
+	}
+	return graph, nil
+}
+
+// readPeerFile decodes one (possibly compressed) JSON file into v
+func readPeerFile(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open peer list %q: %w", path, err)
+	}
+	defer f.Close()
+
+	r, closeReader, err := decompressReader(f)
+	if err != nil {
+		return fmt.Errorf("peer list %q: %w", path, err)
+	}
+	defer closeReader()
+
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("cannot parse peer list %q: %w", path, err)
+	}
+	return nil
+}
+
+// add records peers for domain, normalizing and deduplicating both
+func (g PeerGraph) add(domain string, peers []string) {
+	domain = normalizeDomain(domain)
+	if _, ok := g[domain]; !ok {
+		g[domain] = []string{} // An empty list still means the instance reported its peers
+	}
+	seen := make(map[string]bool, len(g[domain]))
+	for _, p := range g[domain] {
+		seen[p] = true
+	}
+	for _, p := range peers {
+		p = normalizeDomain(p)
+		if p == "" || p == domain || seen[p] {
+			continue
+		}
+		seen[p] = true
+		g[domain] = append(g[domain], p)
+	}
+	sort.Strings(g[domain])
+}
+
+// AttachPeers copies each instance's peer list from graph onto the instance
+// and returns how many instances had one
+func AttachPeers(instances []Instance, graph PeerGraph) int {
+	matched := 0
+	for i := range instances {
+		if peers, ok := graph[normalizeDomain(instances[i].Domain)]; ok {
+			instances[i].Peers = peers
+			matched++
+		}
+	}
+	return matched
+}
@@ -9,16 +9,26 @@ import (
 // Helper Functions
 // ============================================================================
 
-// isSuperGiant checks if a domain is one of the three galactic core supergiants
+// isSuperGiant checks if a domain is one of the configured galactic core supergiants
 func isSuperGiant(domain string, cfg Config) bool {
-	for _, d := range cfg.SupergiantDomains {
-		if d == domain {
+	for _, sg := range cfg.Supergiants {
+		if sg.Domain == domain {
 			return true
 		}
 	}
 	return false
 }
 
+// supergiantForSoftware returns the supergiant whose spiral arm belongs to software
+func supergiantForSoftware(software string, cfg Config) (Supergiant, bool) {
+	for _, sg := range cfg.Supergiants {
+		if sg.Software != "" && sg.Software == software {
+			return sg, true
+		}
+	}
+	return Supergiant{}, false
+}
+
 // getSoftwareName safely extracts software name from instance
 func getSoftwareName(instance *Instance) string {
 	if instance.Software != nil && instance.Software.Name != "" {
@@ -145,31 +155,36 @@ func rotatePoint(p Position, seed string) Position {
 // Supergiant Positions
 // ============================================================================
 
+// getSuperGiantPosition returns a supergiant's explicit position, or its
+// corner of a regular polygon in the XY plane centered on the origin. The
+// first corner points along +Y and the rest follow counter-clockwise, so the
+// default three supergiants form an equilateral triangle. It returns nil for
+// domains that are not supergiants.
 func getSuperGiantPosition(domain string, cfg Config) *Position {
-	// Form an equilateral triangle in the XY plane, centered at origin
-	// All three points are at distance r from the origin, separated by 120°
-	r := cfg.SupergiantRadius
-
-	// 120° = 2π/3 radians apart
-	// Point 0: angle 90° (top)
-	// Point 1: angle 210° (bottom-left)
-	// Point 2: angle 330° (bottom-right)
-
-	switch domain {
-	case "mastodon.social":
-		// 90° (π/2): cos=0, sin=1
-		return &Position{X: 0, Y: r, Z: 0}
-
-	case "misskey.io":
-		// 210° (7π/6): cos=-√3/2≈-0.866, sin=-0.5
-		return &Position{X: r * (-0.866), Y: r * (-0.5), Z: 0}
-
-	case "pixelfed.social":
-		// 330° (11π/6): cos=√3/2≈0.866, sin=-0.5
-		return &Position{X: r * 0.866, Y: r * (-0.5), Z: 0}
+	corner, corners := -1, 0
+	for _, sg := range cfg.Supergiants {
+		if sg.Domain == domain && sg.Position != nil {
+			p := *sg.Position
+			return &p
+		}
+		if sg.Position != nil {
+			continue
+		}
+		if sg.Domain == domain {
+			corner = corners
+		}
+		corners++
+	}
+	if corner < 0 {
+		return nil
+	}
 
-	default:
-		return &Position{X: 0, Y: 0, Z: 0}
+	r := cfg.SupergiantRadius
+	angle := math.Pi/2 + 2*math.Pi*float64(corner)/float64(corners)
+	return &Position{
+		X: math.Round(r*math.Cos(angle)*10) / 10,
+		Y: math.Round(r*math.Sin(angle)*10) / 10,
+		Z: 0,
 	}
 }
 
@@ -181,19 +196,38 @@ type TierInfo struct {
 	Tier          string
 	InstanceCount int
 	Software      string
-	ArmIndex      int // Which spiral arm (0-4 for Tier A)
+	ArmIndex      int // Which spiral arm (0 to galaxy.arms-1 for Tier A)
 }
 
-// Main spiral arms: 5 major arms for Tier A software
-const NUM_MAIN_ARMS = 5
-
 func calculateSystemCenters(softwareTiers map[string]TierInfo, cfg Config) map[string]Position {
 	centers := make(map[string]Position)
 
-	// Separate software types by tier
-	tierA := []TierInfo{}
-	tierB := []TierInfo{}
-	tierC := []TierInfo{}
+	tierA, tierB, tierC := splitTiers(softwareTiers)
+
+	// Assign arm indices to Tier A
+	for i := range tierA {
+		tierA[i].ArmIndex = i % cfg.Galaxy.Arms
+	}
+
+	// Tier A: Main spiral arms (logarithmic spiral)
+	distributeSpiralArms(tierA, centers, cfg)
+
+	// Tier B: Branch arms (short spirals branching from main arms)
+	distributeBranchArms(tierB, centers, cfg)
+
+	// Tier C: Central bulge (spherical distribution around core)
+	distributeCentralBulge(tierC, centers, cfg)
+
+	return centers
+}
+
+// splitTiers separates software types by tier. Each tier is sorted by
+// instance count (largest first), breaking ties by name so the layout does
+// not depend on map iteration order.
+func splitTiers(softwareTiers map[string]TierInfo) (tierA, tierB, tierC []TierInfo) {
+	tierA = []TierInfo{}
+	tierB = []TierInfo{}
+	tierC = []TierInfo{}
 
 	for _, tier := range softwareTiers {
 		switch tier.Tier {
@@ -206,53 +240,38 @@ func calculateSystemCenters(softwareTiers map[string]TierInfo, cfg Config) map[s
 		}
 	}
 
-	// Sort each tier by instance count (largest first) for consistent ordering
 	sort.Slice(tierA, func(i, j int) bool {
-		return tierA[i].InstanceCount > tierA[j].InstanceCount
+		return tierLess(tierA[i], tierA[j])
 	})
 	sort.Slice(tierB, func(i, j int) bool {
-		return tierB[i].InstanceCount > tierB[j].InstanceCount
+		return tierLess(tierB[i], tierB[j])
 	})
 	sort.Slice(tierC, func(i, j int) bool {
-		return tierC[i].InstanceCount > tierC[j].InstanceCount
+		return tierLess(tierC[i], tierC[j])
 	})
+	return tierA, tierB, tierC
+}
 
-	// Assign arm indices to Tier A
-	for i := range tierA {
-		tierA[i].ArmIndex = i % NUM_MAIN_ARMS
+// tierLess orders software by instance count (largest first), then by name
+func tierLess(a, b TierInfo) bool {
+	if a.InstanceCount != b.InstanceCount {
+		return a.InstanceCount > b.InstanceCount
 	}
-
-	// Tier A: Main spiral arms (logarithmic spiral)
-	distributeSpiralArms(tierA, centers, cfg)
-
-	// Tier B: Branch arms (short spirals branching from main arms)
-	distributeBranchArms(tierB, centers, cfg)
-
-	// Tier C: Central bulge (spherical distribution around core)
-	distributeCentralBulge(tierC, centers, cfg)
-
-	return centers
+	return a.Software < b.Software
 }
 
 // Tier A: Main spiral arms using logarithmic spiral
 func distributeSpiralArms(tiers []TierInfo, centers map[string]Position, cfg Config) {
 	for _, tierInfo := range tiers {
-		// Check if this software has a corresponding supergiant instance
-		// (Mastodon -> mastodon.social, Misskey -> misskey.io, Pixelfed -> pixelfed.social)
+		// Check if this software owns a supergiant (e.g. Mastodon -> mastodon.social)
 		var superGiantOrigin *Position
-		superGiantMap := map[string]string{
-			"Mastodon": "mastodon.social",
-			"Misskey":  "misskey.io",
-			"Pixelfed": "pixelfed.social",
-		}
-
-		if superGiantDomain, exists := superGiantMap[tierInfo.Software]; exists {
-			origin := getSuperGiantPosition(superGiantDomain, cfg)
-			superGiantOrigin = origin
+		if sg, ok := supergiantForSoftware(tierInfo.Software, cfg); ok {
+			superGiantOrigin = getSuperGiantPosition(sg.Domain, cfg)
 		}
 
+		g := cfg.Galaxy
 		armIndex := tierInfo.ArmIndex
-		baseAngle := 2.0 * math.Pi * float64(armIndex) / NUM_MAIN_ARMS
+		baseAngle := 2.0 * math.Pi * float64(armIndex) / float64(g.Arms)
 
 		// Each arm gets a position along its spiral
 		// Multiple tier A software on same arm -> stagger them radially
@@ -262,18 +281,16 @@ func distributeSpiralArms(tiers []TierInfo, centers map[string]Position, cfg Con
 		// Logarithmic spiral: r = a * exp(b * theta)
 		// theta increases as we go outward along the arm
 		progress := float64(armPosition) / math.Max(float64(totalInArm), 1.0)
-		theta := progress * 2.5 * math.Pi // 1.25 rotations along arm
+		theta := progress * 2 * g.Turns * math.Pi
 
 		var finalPosition Position
 
 		if superGiantOrigin != nil {
-			// For Mastodon/Misskey/Pixelfed, the spiral arm starts from the supergiant position
+			// For software with a supergiant, the spiral arm starts from the supergiant position
 			// and extends outward in a logarithmic spiral centered on the supergiant
 
 			// Logarithmic spiral parameters (similar to non-supergiant case but starting from supergiant)
-			a := 2000.0 // Starting radius from supergiant
-			b := 0.25   // Spiral tightness
-			radius := a * math.Exp(b*theta)
+			radius := g.SupergiantStartRadius * math.Exp(g.Pitch*theta)
 
 			// Base angle determined by the supergiant's position angle
 			// This ensures the spiral extends in a natural direction from the supergiant
@@ -288,7 +305,7 @@ func distributeSpiralArms(tiers []TierInfo, centers map[string]Position, cfg Con
 
 			// Add vertical wave
 			zHash := domainHash(tierInfo.Software + "_z")
-			waveAmplitude := radius * 0.15
+			waveAmplitude := radius * g.WaveAmplitude
 			zWave := math.Sin(theta*2.0) * waveAmplitude
 			zVariation := (zHash - 0.5) * radius * 0.05
 			z := superGiantOrigin.Z + zWave + zVariation
@@ -300,15 +317,13 @@ func distributeSpiralArms(tiers []TierInfo, centers map[string]Position, cfg Con
 			}
 		} else {
 			// Original logic for software without a supergiant
-			a := 5000.0 // Starting radius
-			b := 0.25   // Spiral tightness
-			radius := a * math.Exp(b*theta)
+			radius := g.StartRadius * math.Exp(g.Pitch*theta)
 
 			angle := baseAngle + theta
 
 			// Add vertical wave to spiral arms (makes them 3D)
 			zHash := domainHash(tierInfo.Software + "_z")
-			waveAmplitude := radius * 0.15
+			waveAmplitude := radius * g.WaveAmplitude
 			zWave := math.Sin(theta*2.0) * waveAmplitude
 			zVariation := (zHash - 0.5) * radius * 0.05
 			z := zWave + zVariation
@@ -328,8 +343,8 @@ func distributeSpiralArms(tiers []TierInfo, centers map[string]Position, cfg Con
 func distributeBranchArms(tiers []TierInfo, centers map[string]Position, cfg Config) {
 	for i, tierInfo := range tiers {
 		// Each B-tier branches from a main arm
-		parentArm := i % NUM_MAIN_ARMS
-		baseAngle := 2.0 * math.Pi * float64(parentArm) / NUM_MAIN_ARMS
+		parentArm := i % cfg.Galaxy.Arms
+		baseAngle := 2.0 * math.Pi * float64(parentArm) / float64(cfg.Galaxy.Arms)
 
 		// Branch offset angle
 		branchHash := domainHash(tierInfo.Software + "_branch")
@@ -441,6 +456,15 @@ func calculateInstancePosition(instance *Instance, systemCenter Position, system
 	}
 }
 
+// systemSlotPosition places a system member at a lattice slot: slot 0, the
+// largest member's, is the system center and the rest lie on the sphere
+func systemSlotPosition(instance *Instance, systemCenter Position, systemMaxRadius float64, slot, total int, softwareSeed string, cfg Config) *Position {
+	if slot == 0 {
+		return &Position{X: systemCenter.X, Y: systemCenter.Y, Z: systemCenter.Z}
+	}
+	return calculateInstancePosition(instance, systemCenter, systemMaxRadius, slot, total, softwareSeed, cfg)
+}
+
 func constrain(value, min, max float64) float64 {
 	if value < min {
 		return min
@@ -455,29 +479,57 @@ func constrain(value, min, max float64) float64 {
 // Unknown Software - Interstellar Dust Cloud Distribution
 // ============================================================================
 
-func calculateOuterRimPosition(instance *Instance, cfg Config) *Position {
-	// Use domain hash to determine distribution strategy
+// dustStrategies lists the unknown-software strategies in selection order
+var dustStrategies = []string{"inner", "arm", "nebula", "halo"}
+
+// pickDustStrategy chooses a strategy for domain with probability
+// proportional to its weight; strategies with no weight are never chosen
+func pickDustStrategy(domain string, weights map[string]float64) string {
+	total := 0.0
+	for _, s := range dustStrategies {
+		total += weights[s]
+	}
+	strategyHash := domainHash(domain + "_strategy")
+	cumulative := 0.0
+	chosen := ""
+	for _, s := range dustStrategies {
+		if weights[s] <= 0 {
+			continue
+		}
+		cumulative += weights[s]
+		chosen = s
+		if strategyHash < cumulative/total {
+			break
+		}
+	}
+	return chosen
+}
+
+// calculateOuterRimPosition places an unknown-software instance with one of
+// the dust strategies (see dust.weights) and returns the strategy used
+func calculateOuterRimPosition(instance *Instance, cfg Config) (*Position, string) {
 	hash := domainHash(instance.Domain)
-	strategyHash := domainHash(instance.Domain + "_strategy")
 
-	// Distribution strategies (weighted):
+	// Distribution strategies (default weights):
 	// 65% - Inner dust (within known software systems range, 2k-10k radius)
-	// 20% - Spiral arm dust (along the 5 main arms, outer regions)
+	// 20% - Spiral arm dust (along the main arms, outer regions)
 	// 10% - Clustered nebulae (small dense clusters)
 	// 5%  - Outer halo (diffuse outer region, 25k-40k radius)
-
-	if strategyHash < 0.65 {
-		// Strategy 1: Inner Dust (NEW - fills space between known systems)
-		return calculateInnerDust(instance, hash, cfg)
-	} else if strategyHash < 0.85 {
+	strategy := pickDustStrategy(instance.Domain, cfg.Dust.Weights)
+	switch strategy {
+	case "inner":
+		// Strategy 1: Inner Dust (fills space between known systems)
+		return calculateInnerDust(instance, hash, cfg), strategy
+	case "arm":
 		// Strategy 2: Spiral Arm Dust
-		return calculateSpiralArmDust(instance, hash, cfg)
-	} else if strategyHash < 0.95 {
-		// Strategy 3: Clustered Nebulae
-		return calculateClusteredNebula(instance, hash, cfg)
-	} else {
+		return calculateSpiralArmDust(instance, hash, cfg), strategy
+	case "nebula":
+		// Strategy 3: Clustered Nebulae, named so the frontend can show them
+		instance.Nebula = nebulaKey(instance, cfg)
+		return calculateClusteredNebula(instance, instance.Nebula, cfg), strategy
+	default:
 		// Strategy 4: Outer Halo
-		return calculateOuterHalo(instance, hash, cfg)
+		return calculateOuterHalo(instance, hash, cfg), "halo"
 	}
 }
 
@@ -492,9 +544,9 @@ func calculateInnerDust(instance *Instance, hash float64, cfg Config) *Position
 	phiHash := domainHash(instance.Domain + "_phi")
 	phi := math.Acos(2.0*phiHash - 1.0)
 
-	// Distance: within the main galaxy disk (2k-10k from center)
+	// Distance: within the main galaxy disk (2k-10k from center by default)
 	distHash := domainHash(instance.Domain + "_dist")
-	distance := 2000.0 + distHash*8000.0 // 2k-10k radius
+	distance := cfg.Dust.InnerMinRadius + distHash*(cfg.Dust.InnerMaxRadius-cfg.Dust.InnerMinRadius)
 
 	// Spherical distribution (no Z-axis compression)
 	x := distance * math.Sin(phi) * math.Cos(angle)
@@ -506,18 +558,20 @@ func calculateInnerDust(instance *Instance, hash float64, cfg Config) *Position
 
 // Strategy 2: Distribute along spiral arms with natural scatter
 func calculateSpiralArmDust(instance *Instance, hash float64, cfg Config) *Position {
-	// Choose which spiral arm (0-4)
+	g := cfg.Galaxy
+
+	// Choose which spiral arm, using the same angles as the main arms
 	armHash := domainHash(instance.Domain + "_arm")
-	armIndex := int(math.Floor(armHash * NUM_MAIN_ARMS))
-	baseAngle := 2.0 * math.Pi * float64(armIndex) / NUM_MAIN_ARMS
+	armIndex := int(math.Floor(armHash * float64(g.Arms)))
+	baseAngle := 2.0 * math.Pi * float64(armIndex) / float64(g.Arms)
 
 	// Position along the arm (logarithmic spiral)
 	progressHash := domainHash(instance.Domain + "_progress")
-	theta := progressHash * 2.5 * math.Pi // Follow arm rotation
+	theta := progressHash * 2 * g.Turns * math.Pi // Follow arm rotation
 
-	// Distance: slightly beyond the main systems
-	a := 8000.0 // Start further out than main systems
-	b := 0.30   // Slightly looser spiral
+	// Distance: slightly beyond the main systems, on a slightly looser spiral
+	a := g.StartRadius * g.DustRadiusScale
+	b := g.Pitch * g.DustPitchScale
 	baseRadius := a * math.Exp(b*theta)
 
 	// Add perpendicular scatter (drift away from arm center)
@@ -528,7 +582,7 @@ func calculateSpiralArmDust(instance *Instance, hash float64, cfg Config) *Posit
 
 	// Z variation: waves along the arm
 	zWaveHash := domainHash(instance.Domain + "_zwave")
-	zWave := math.Sin(theta*3.0) * baseRadius * 0.12
+	zWave := math.Sin(theta*3.0) * baseRadius * (g.WaveAmplitude * g.DustWaveScale)
 	zScatter := (zWaveHash - 0.5) * 2500.0
 	z := zWave + zScatter
 
@@ -540,18 +594,14 @@ func calculateSpiralArmDust(instance *Instance, hash float64, cfg Config) *Posit
 	return &Position{X: x, Y: y, Z: z}
 }
 
-// Strategy 3: Form small dense clusters (nebulae)
-func calculateClusteredNebula(instance *Instance, hash float64, cfg Config) *Position {
-	// Determine cluster center (use first 6 chars of domain as cluster ID)
-	clusterSeed := instance.Domain
-	if len(clusterSeed) > 6 {
-		clusterSeed = clusterSeed[:6]
-	}
+// Strategy 3: Form small dense clusters (nebulae). Every instance with the
+// same clusterSeed (its nebula key, see dust.nebula_key) shares a center.
+func calculateClusteredNebula(instance *Instance, clusterSeed string, cfg Config) *Position {
 
 	// Cluster center in cylindrical coordinates
 	clusterHash := domainHash(clusterSeed + "_cluster")
 	clusterAngle := clusterHash * 2.0 * math.Pi
-	clusterDist := 10000.0 + clusterHash*12000.0 // 10k-22k from center
+	clusterDist := cfg.Dust.NebulaMinRadius + clusterHash*(cfg.Dust.NebulaMaxRadius-cfg.Dust.NebulaMinRadius)
 
 	clusterZHash := domainHash(clusterSeed + "_clusterZ")
 	clusterZ := (clusterZHash - 0.5) * 8000.0 // ±4000 units
@@ -568,7 +618,7 @@ func calculateClusteredNebula(instance *Instance, hash float64, cfg Config) *Pos
 
 	// Cluster radius (tight grouping)
 	radiusHash := domainHash(instance.Domain + "_radius")
-	localRadius := radiusHash * 800.0 // 0-800 units (very tight)
+	localRadius := radiusHash * cfg.Dust.NebulaSize // 0-800 units by default (very tight)
 
 	localX := localRadius * math.Sin(localPhi) * math.Cos(localAngle)
 	localY := localRadius * math.Sin(localPhi) * math.Sin(localAngle)
@@ -591,7 +641,7 @@ func calculateOuterHalo(instance *Instance, hash float64, cfg Config) *Position
 
 	// Distance: far outer region
 	distHash := domainHash(instance.Domain + "_dist")
-	distance := 25000.0 + distHash*15000.0 // 25k-40k
+	distance := cfg.Dust.HaloMinRadius + distHash*(cfg.Dust.HaloMaxRadius-cfg.Dust.HaloMinRadius)
 
 	return &Position{
 		X: distance * math.Sin(phi) * math.Cos(angle),
@@ -601,10 +651,37 @@ func calculateOuterHalo(instance *Instance, hash float64, cfg Config) *Position
 }
 
 // ============================================================================
-// Main Processing Function
+// Spiral Galaxy Layout
 // ============================================================================
 
-func ProcessPositions(instances []Instance, cfg Config) []Instance {
+func init() {
+	registerLayout(spiralLayout{})
+}
+
+// spiralLayout is the original layout: supergiants in the core, software
+// systems along spiral and branch arms, and unknown software as outer dust
+type spiralLayout struct{}
+
+func (spiralLayout) Name() string { return "spiral" }
+
+func (spiralLayout) Description() string {
+	return "Supergiant core with software systems along spiral arms (default)"
+}
+
+func (spiralLayout) Place(instances []Instance, cfg Config) []Instance {
+	return placeGalaxy(instances, cfg, calculateSystemCenters, calculateOuterRimPosition)
+}
+
+// placeGalaxy runs the pipeline shared by the galaxy layouts: classify each
+// software into a tier, let centers place the system centers, fill every
+// system with calculateInstancePosition, and hand unknown software to dust.
+// Supergiants keep their configured positions in every morphology.
+func placeGalaxy(
+	instances []Instance,
+	cfg Config,
+	centers func(softwareTiers map[string]TierInfo, cfg Config) map[string]Position,
+	dust func(instance *Instance, cfg Config) (*Position, string),
+) []Instance {
 	// Step 1: Group instances by software type
 	bySoftware := make(map[string][]int)
 	for i := range instances {
@@ -616,7 +693,7 @@ func ProcessPositions(instances []Instance, cfg Config) []Instance {
 	// IMPORTANT: Exclude "Unknown" software - it uses special dust cloud distribution
 	softwareTiers := make(map[string]TierInfo)
 	for software, indices := range bySoftware {
-		// Skip "Unknown" - it will be handled by calculateOuterRimPosition
+		// Skip "Unknown" - it will be handled by the dust placement
 		if software == "Unknown" {
 			continue
 		}
@@ -630,8 +707,8 @@ func ProcessPositions(instances []Instance, cfg Config) []Instance {
 		}
 	}
 
-	// Step 3: Calculate planetary system centers using Fibonacci lattice
-	systemCenters := calculateSystemCenters(softwareTiers, cfg)
+	// Step 3: Calculate planetary system centers for this morphology
+	systemCenters := centers(softwareTiers, cfg)
 
 	// Step 4: Calculate system max radii
 	systemRadii := make(map[string]float64)
@@ -643,19 +720,48 @@ func ProcessPositions(instances []Instance, cfg Config) []Instance {
 		)
 	}
 
-	// Step 5: Sort instances within each software type by user count
-	for software, indices := range bySoftware {
+	// Step 5: Sort instances within each software type by user count and
+	// record each instance's rank within its group, keyed by input index
+	rank := make([]int, len(instances))
+	for _, indices := range bySoftware {
 		sort.Slice(indices, func(a, b int) bool {
 			ucA := getInstanceUserCount(&instances[indices[a]])
 			ucB := getInstanceUserCount(&instances[indices[b]])
 			return ucA > ucB
 		})
-		bySoftware[software] = indices
+		for r, idx := range indices {
+			rank[idx] = r
+		}
 	}
 
-	// Step 6: Process each instance
+	// Step 5b: With a previous output, members that kept their system and
+	// size class keep their place relative to the system center, and the
+	// newcomers are fitted in around them
+	kept := make([]*Position, len(instances))
+	previous := previousCenters(instances, centers, cfg)
+	for software, indices := range bySoftware {
+		center, ok := systemCenters[software]
+		previousCenter, wasPlaced := previous[software]
+		if !ok || !wasPlaced {
+			continue
+		}
+		anyKept := false
+		for _, idx := range indices {
+			inst := &instances[idx]
+			if !isSuperGiant(inst.Domain, cfg) && keepsPlacement(inst, software, classifyInstanceSize(getInstanceUserCount(inst), cfg)) {
+				kept[idx] = keptPosition(inst.Previous.Position, previousCenter, center)
+				anyKept = true
+			}
+		}
+		if anyKept {
+			assignSlots(instances, indices, kept, center, systemRadii[software], software, cfg, rank)
+		}
+	}
+
+	// Step 6: Process each instance. Every step reads only the shared tables
+	// above and writes only result[i], so workers cannot change the output.
 	result := make([]Instance, len(instances))
-	for i := range instances {
+	parallelFor(len(instances), cfg.Workers, func(i int) {
 		result[i] = instances[i]
 		instance := &result[i]
 		software := getSoftwareName(instance)
@@ -663,48 +769,44 @@ func ProcessPositions(instances []Instance, cfg Config) []Instance {
 		if isSuperGiant(instance.Domain, cfg) {
 			instance.Position = getSuperGiantPosition(instance.Domain, cfg)
 			instance.PositionType = "supergiant"
-			continue
+			return
 		}
 
 		systemCenter, ok := systemCenters[software]
 		if !ok {
-			instance.Position = calculateOuterRimPosition(instance, cfg)
 			instance.PositionType = "unknown"
-			continue
+			// A kept dust instance keeps its nebula too; its strategy is
+			// not in the output, so it is left out of the strategy counts
+			if keepsPlacement(instance, software, "unknown") {
+				p := instance.Previous.Position
+				instance.Position = &p
+				instance.Nebula = instance.Previous.Nebula
+				instance.Anchored = true
+				return
+			}
+			instance.Position, instance.DustStrategy = dust(instance, cfg)
+			return
 		}
 
 		systemMaxRadius := systemRadii[software]
 		userCount := getInstanceUserCount(instance)
 
-		indices := bySoftware[software]
-		total := len(indices)
-
-		rank := -1
-		for r, idx := range indices {
-			if instances[idx].Domain == instance.Domain {
-				rank = r
-				break
-			}
-		}
-
-		isLargest := rank == 0
-
-		if isLargest && !isSuperGiant(instance.Domain, cfg) {
-			instance.Position = &Position{
-				X: systemCenter.X,
-				Y: systemCenter.Y,
-				Z: systemCenter.Z,
-			}
-			instance.PositionType = classifyInstanceSize(userCount, cfg)
-		} else {
-			instance.Position = calculateInstancePosition(instance, systemCenter, systemMaxRadius, rank, total, software, cfg)
+		if kept[i] != nil {
+			p := *kept[i]
+			instance.Position = &p
 			instance.PositionType = classifyInstanceSize(userCount, cfg)
+			instance.Anchored = true
+			return
 		}
 
+		total := len(bySoftware[software])
+		instance.Position = systemSlotPosition(instance, systemCenter, systemMaxRadius, rank[i], total, software, cfg)
+		instance.PositionType = classifyInstanceSize(userCount, cfg)
+
 		instance.Position.X = math.Round(instance.Position.X*10) / 10
 		instance.Position.Y = math.Round(instance.Position.Y*10) / 10
 		instance.Position.Z = math.Round(instance.Position.Z*10) / 10
-	}
+	})
 
 	return result
 }
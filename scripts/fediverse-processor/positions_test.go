@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"math"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +72,75 @@ func TestGetSuperGiantPosition_ProperSpacing(t *testing.T) {
 	}
 }
 
+func TestGetSuperGiantPosition_RegularPolygon(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Supergiants = append(cfg.Supergiants, Supergiant{Domain: "fork.example", Software: "Sharkey"})
+
+	// Four supergiants form a square: each at the radius, 90° apart
+	for _, sg := range cfg.Supergiants {
+		p := getSuperGiantPosition(sg.Domain, cfg)
+		if d := math.Hypot(p.X, p.Y); math.Abs(d-cfg.SupergiantRadius) > 0.1 {
+			t.Errorf("%s is %.1f from the core, want %.0f", sg.Domain, d, cfg.SupergiantRadius)
+		}
+	}
+	if p := getSuperGiantPosition("misskey.io", cfg); *p != (Position{X: -cfg.SupergiantRadius}) {
+		t.Errorf("Second corner of a square should be at (-r, 0, 0), got %+v", p)
+	}
+	if getSuperGiantPosition("pawoo.net", cfg) != nil {
+		t.Error("Non-supergiants should have no supergiant position")
+	}
+}
+
+func TestGetSuperGiantPosition_ExplicitCoordinates(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Supergiants = []Supergiant{
+		{Domain: "a.example", Position: &Position{X: 10, Y: 20, Z: 30}},
+		{Domain: "b.example"},
+		{Domain: "c.example"},
+	}
+
+	if p := getSuperGiantPosition("a.example", cfg); *p != (Position{X: 10, Y: 20, Z: 30}) {
+		t.Errorf("Explicit position not used: %+v", p)
+	}
+	// The other two share the polygon between themselves
+	b, c := getSuperGiantPosition("b.example", cfg), getSuperGiantPosition("c.example", cfg)
+	if *b != (Position{Y: cfg.SupergiantRadius}) || *c != (Position{Y: -cfg.SupergiantRadius}) {
+		t.Errorf("Expected b and c on opposite corners, got %+v and %+v", b, c)
+	}
+}
+
+func TestProcessPositions_SupergiantDrivesArmOrigin(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Supergiants = append(cfg.Supergiants, Supergiant{Domain: "fork.example", Software: "Sharkey"})
+
+	var instances []Instance
+	for i := 0; i < cfg.TierAInstanceCount; i++ {
+		instances = append(instances, Instance{
+			Domain:   fmt.Sprintf("s%d.example", i),
+			Software: &Software{Name: "Sharkey"},
+			Stats:    &Stats{UserCount: 10 + i},
+		})
+	}
+	instances = append(instances, Instance{Domain: "fork.example", Software: &Software{Name: "Sharkey"}, Stats: &Stats{UserCount: 100000}})
+	result := ProcessPositions(instances, cfg)
+
+	fork := result[len(result)-1]
+	if fork.PositionType != "supergiant" || *fork.Position != *getSuperGiantPosition("fork.example", cfg) {
+		t.Fatalf("A fourth supergiant should sit on its polygon corner, got %s at %+v", fork.PositionType, fork.Position)
+	}
+
+	// The arm starts 2000 from the supergiant, so its center is far closer
+	// to the fork than to the core
+	centers := calculateSystemCenters(map[string]TierInfo{
+		"Sharkey": {Tier: "A", InstanceCount: len(instances), Software: "Sharkey"},
+	}, cfg)
+	c := centers["Sharkey"]
+	origin := fork.Position
+	if d := math.Sqrt(math.Pow(c.X-origin.X, 2) + math.Pow(c.Y-origin.Y, 2)); math.Abs(d-2000) > 1 {
+		t.Errorf("Sharkey's arm should start 2000 from its supergiant, got %.1f", d)
+	}
+}
+
 // ============================================================
 // B. Helper Function Tests
 // ============================================================
@@ -260,6 +332,69 @@ func TestCalculateSystemCenters_TierDistances(t *testing.T) {
 	}
 }
 
+func TestCalculateSystemCenters_ConfiguredArmCount(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Galaxy.Arms = 3
+	cfg.Supergiants = nil
+
+	tiers := map[string]TierInfo{}
+	for i, sw := range []string{"A", "B", "C", "D", "E", "F"} {
+		tiers[sw] = TierInfo{Tier: "A", InstanceCount: 1000 - i, Software: sw}
+	}
+	centers := calculateSystemCenters(tiers, cfg)
+
+	// The fourth system wraps around to the first arm, one step further out
+	if math.Hypot(centers["D"].X, centers["D"].Y) <= math.Hypot(centers["A"].X, centers["A"].Y) {
+		t.Errorf("Expected D further along arm 0 than A: %+v vs %+v", centers["D"], centers["A"])
+	}
+	// The first system on each arm starts at the arm's base angle
+	for k, sw := range []string{"A", "B", "C"} {
+		want := 2 * math.Pi * float64(k) / 3
+		got := math.Atan2(centers[sw].Y, centers[sw].X)
+		if d := math.Abs(math.Remainder(got-want, 2*math.Pi)); d > 1e-9 {
+			t.Errorf("%s at angle %.3f, want %.3f", sw, got, want)
+		}
+		if r := math.Hypot(centers[sw].X, centers[sw].Y); math.Abs(r-cfg.Galaxy.StartRadius) > 1e-6 {
+			t.Errorf("%s at radius %.1f, want start_radius %.0f", sw, r, cfg.Galaxy.StartRadius)
+		}
+	}
+}
+
+func TestSpiralArmDust_FollowsMainArms(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Galaxy.Arms = 3
+	cfg.Galaxy.Pitch = 0.1
+	cfg.Galaxy.Turns = 0.75
+	g := cfg.Galaxy
+
+	for i := 0; i < 200; i++ {
+		inst := &Instance{Domain: fmt.Sprintf("dust%d.example", i)}
+		p := calculateSpiralArmDust(inst, 0, cfg)
+
+		// Centre line of the arm this dust belongs to, at the same angle the
+		// main arms use for the same arm index and progress
+		arm := math.Floor(domainHash(inst.Domain+"_arm") * float64(g.Arms))
+		theta := domainHash(inst.Domain+"_progress") * 2 * g.Turns * math.Pi
+		angle := 2*math.Pi*arm/float64(g.Arms) + theta
+		r := g.StartRadius * g.DustRadiusScale * math.Exp(g.Pitch*g.DustPitchScale*theta)
+
+		// Only the ±1500 perpendicular scatter separates dust from its arm
+		if d := math.Hypot(p.X-r*math.Cos(angle), p.Y-r*math.Sin(angle)); d > 1500+1e-6 {
+			t.Fatalf("%s is %.0f from its arm's centre line", inst.Domain, d)
+		}
+	}
+}
+
+func TestValidate_GalaxyMorphology(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Galaxy.Arms = 0
+	cfg.Galaxy.Turns = -1
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "galaxy.arms") || !strings.Contains(err.Error(), "galaxy.turns") {
+		t.Errorf("Expected galaxy.arms and galaxy.turns problems, got %v", err)
+	}
+}
+
 // ============================================================
 // D. Instance Position Calculation Tests
 // ============================================================
@@ -539,3 +674,183 @@ func TestProcessPositions_EmptyInstances(t *testing.T) {
 		t.Error("Empty input should produce empty output")
 	}
 }
+
+// ============================================================
+// G. Determinism Tests
+// ============================================================
+
+func TestCalculateSystemCenters_TiesAreDeterministic(t *testing.T) {
+	cfg := DefaultConfig
+	tiers := map[string]TierInfo{}
+	for _, sw := range []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon", "Zeta", "Eta"} {
+		tiers[sw] = TierInfo{Tier: "A", InstanceCount: 150, Software: sw}
+	}
+
+	first := calculateSystemCenters(tiers, cfg)
+	for i := 0; i < 20; i++ {
+		again := calculateSystemCenters(tiers, cfg)
+		for sw, pos := range first {
+			if again[sw] != pos {
+				t.Fatalf("Center for %s changed between runs: %+v vs %+v", sw, pos, again[sw])
+			}
+		}
+	}
+}
+
+func TestProcessPositions_RepeatedDomainsGetDistinctRanks(t *testing.T) {
+	cfg := DefaultConfig
+	instances := []Instance{
+		{Domain: "dup.test", Software: &Software{Name: "Lemmy"}, Stats: &Stats{UserCount: 500}},
+		{Domain: "dup.test", Software: &Software{Name: "Lemmy"}, Stats: &Stats{UserCount: 50}},
+		{Domain: "other.test", Software: &Software{Name: "Lemmy"}, Stats: &Stats{UserCount: 5}},
+	}
+
+	result := spiralLayout{}.Place(instances, cfg)
+	if *result[0].Position == *result[1].Position {
+		t.Error("Instances sharing a domain should not share a rank (and position)")
+	}
+}
+
+// ============================================================
+// H. Dust Strategy Tests
+// ============================================================
+
+func TestPickDustStrategy_FollowsWeights(t *testing.T) {
+	weights := map[string]float64{"inner": 3, "halo": 1}
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[pickDustStrategy(fmt.Sprintf("u%d.example", i), weights)]++
+	}
+	if len(counts) != 2 || counts["arm"] != 0 || counts["nebula"] != 0 {
+		t.Fatalf("Only weighted strategies should be chosen, got %v", counts)
+	}
+	if share := float64(counts["inner"]) / 4000; math.Abs(share-0.75) > 0.03 {
+		t.Errorf("inner share %.3f, want about 0.75", share)
+	}
+}
+
+func TestCalculateOuterRimPosition_ConfiguredDistances(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Dust.Weights = map[string]float64{"halo": 1}
+	cfg.Dust.HaloMinRadius = 50000
+	cfg.Dust.HaloMaxRadius = 60000
+
+	for i := 0; i < 100; i++ {
+		inst := &Instance{Domain: fmt.Sprintf("u%d.example", i)}
+		p, strategy := calculateOuterRimPosition(inst, cfg)
+		d := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+		if strategy != "halo" || d < 50000-1e-6 || d > 60000+1e-6 {
+			t.Fatalf("%s: %s at %.0f, want halo within 50k-60k", inst.Domain, strategy, d)
+		}
+	}
+}
+
+func TestDustStats_ReportStrategies(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	var instances []Instance
+	for i := 0; i < 500; i++ {
+		instances = append(instances, Instance{Domain: fmt.Sprintf("u%d.example", i)})
+	}
+	instances = append(instances, layoutFixture()...)
+
+	stats := buildDustStats(ProcessPositions(instances, cfg))
+	total := 0
+	for _, s := range dustStrategies {
+		if stats[s] == 0 {
+			t.Errorf("Expected some instances placed with %s, got %v", s, stats)
+		}
+		total += stats[s]
+	}
+	// x.test has no software either
+	if total != 501 {
+		t.Errorf("Expected every unknown instance counted once, got %d", total)
+	}
+}
+
+func TestValidate_DustWeights(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Dust.Weights = map[string]float64{"comet": 1}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "comet") {
+		t.Errorf("Expected unknown strategy error, got %v", err)
+	}
+
+	cfg.Dust.Weights = map[string]float64{"inner": 0}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dust.weights") {
+		t.Errorf("Expected error when no strategy has weight, got %v", err)
+	}
+
+	cfg = DefaultConfig.clone()
+	cfg.Dust.HaloMinRadius = 50000
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dust.halo_min_radius") {
+		t.Errorf("Expected halo radius order error, got %v", err)
+	}
+}
+
+func TestLoadConfig_DustWeightsOmitStrategies(t *testing.T) {
+	path := writeConfigFile(t, "dust.yaml", "dust:\n  weights:\n    inner: 1\n    halo: 1\n")
+	fromFile, _, err := ResolveConfig(ConfigLayers{File: path})
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %v", err)
+	}
+	fromSet, _, err := ResolveConfig(ConfigLayers{Sets: []string{"dust.weights={inner: 1, halo: 1}"}})
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %v", err)
+	}
+
+	want := map[string]float64{"inner": 1, "halo": 1}
+	for name, cfg := range map[string]Config{"file": fromFile, "-set": fromSet} {
+		if !reflect.DeepEqual(cfg.Dust.Weights, want) {
+			t.Errorf("%s: dust.weights = %v, want %v", name, cfg.Dust.Weights, want)
+		}
+	}
+
+	// Omitted strategies are never chosen
+	for i := 0; i < 200; i++ {
+		inst := &Instance{Domain: fmt.Sprintf("u%d.dust.test", i)}
+		if _, strategy := calculateOuterRimPosition(inst, fromFile); strategy == "arm" || strategy == "nebula" {
+			t.Fatalf("%s used omitted strategy %s", inst.Domain, strategy)
+		}
+	}
+}
+
+// ============================================================
+// I. Benchmarks
+// ============================================================
+
+// syntheticInstances returns n instances with a long-tailed software mix and
+// user counts, shaped like the real dataset
+func syntheticInstances(n int) []Instance {
+	software := []string{"Mastodon", "Misskey", "Pleroma", "Akkoma", "Lemmy", "Pixelfed", "GoToSocial", "PeerTube", "WriteFreely", "Friendica"}
+	instances := make([]Instance, n)
+	for i := range instances {
+		d := fmt.Sprintf("i%d.example", i)
+		inst := Instance{
+			Domain: d,
+			Stats:  &Stats{UserCount: int(math.Pow(10, domainHash(d+"_users")*5))},
+		}
+		// Mastodon takes about half, each later entry half of the remainder,
+		// and ~1/1024 is unknown
+		if k := int(-math.Log2(1 - domainHash(d+"_sw")*0.999)); k < len(software) {
+			inst.Software = &Software{Name: software[k]}
+		}
+		instances[i] = inst
+	}
+	return instances
+}
+
+// BenchmarkProcessPositions should scale near-linearly: ns/instance stays
+// roughly flat from 10k to 1M. Separation stays at its default (off)
+// because its cost depends on how crowded the synthetic galaxy gets.
+func BenchmarkProcessPositions(b *testing.B) {
+	cfg := DefaultConfig.clone()
+	for _, n := range []int{10000, 100000, 1000000} {
+		instances := syntheticInstances(n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ProcessPositions(instances, cfg)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(n), "ns/instance")
+		})
+	}
+}
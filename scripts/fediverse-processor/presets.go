@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Preset is a named alternative galaxy look layered over DefaultConfig
+type Preset struct {
+	Name        string
+	Description string
+	Apply       func(cfg *Config)
+}
+
+// presets is the registry of named presets, selected with -preset
+var presets = []Preset{
+	{
+		Name:        "compact",
+		Description: "Tighter core and smaller planetary systems",
+		Apply: func(cfg *Config) {
+			cfg.SupergiantRadius = 2000
+			cfg.TierASystemMaxRadius = 2500
+			cfg.TierBSystemMaxRadius = 1200
+			cfg.TierCSystemMaxRadius = 600
+			cfg.SystemRadiusScaleFactor = 0.35
+			cfg.RadialVariationFactor = 0.1
+		},
+	},
+	{
+		Name:        "wide-halo",
+		Description: "Large, diffuse planetary systems with loose orbits",
+		Apply: func(cfg *Config) {
+			cfg.SupergiantRadius = 4000
+			cfg.TierASystemMaxRadius = 6000
+			cfg.TierBSystemMaxRadius = 3500
+			cfg.TierCSystemMaxRadius = 2000
+			cfg.SystemRadiusScaleFactor = 0.8
+			cfg.RadialVariationFactor = 0.3
+		},
+	},
+	{
+		Name:        "flat-disk",
+		Description: "Thin galactic disk with barely any vertical wave",
+		Apply: func(cfg *Config) {
+			cfg.Galaxy.WaveAmplitude = 0.03
+			cfg.Galaxy.DustWaveScale = 0.5
+			cfg.Galaxy.EllipticalSigmaZ = 1500
+		},
+	},
+}
+
+// findPreset looks up a preset by name
+func findPreset(name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// presetNames returns the registered preset names in registry order
+func presetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// ApplyPreset layers the named preset over base
+func ApplyPreset(name string, base Config) (Config, error) {
+	p, ok := findPreset(name)
+	if !ok {
+		return base, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(presetNames(), ", "))
+	}
+	cfg := base.clone()
+	p.Apply(&cfg)
+	return cfg, nil
+}
+
+// ConfigDiff describes one key whose value differs between two configs
+type ConfigDiff struct {
+	Key  string
+	From interface{}
+	To   interface{}
+}
+
+// diffConfigs lists the keys whose values differ between a and b, sorted by key
+func diffConfigs(a, b Config) []ConfigDiff {
+	fa, fb := configFieldMap(a), configFieldMap(b)
+	var diffs []ConfigDiff
+	for key, va := range fa {
+		if vb := fb[key]; !reflect.DeepEqual(va, vb) {
+			diffs = append(diffs, ConfigDiff{Key: key, From: va, To: vb})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+// runPresets implements `presets list`
+func runPresets(args []string) int {
+	if len(args) > 0 && args[0] != "list" {
+		fmt.Fprintf(os.Stderr, "USAGE:\n  fediverse-processor presets list\n")
+		return 2
+	}
+
+	for _, p := range presets {
+		cfg, _ := ApplyPreset(p.Name, DefaultConfig)
+		fmt.Printf("%s — %s\n", p.Name, p.Description)
+		for _, d := range diffConfigs(DefaultConfig, cfg) {
+			from, _ := json.Marshal(d.From)
+			to, _ := json.Marshal(d.To)
+			fmt.Printf("  %-28s %s → %s\n", d.Key, from, to)
+		}
+		fmt.Println()
+	}
+	return 0
+}
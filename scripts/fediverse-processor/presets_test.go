@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// ============================================================
+// A. Preset Registry Tests
+// ============================================================
+
+func TestPresets_AllValidAndDistinct(t *testing.T) {
+	for _, name := range presetNames() {
+		cfg, err := ApplyPreset(name, DefaultConfig)
+		if err != nil {
+			t.Fatalf("ApplyPreset(%q) failed: %v", name, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Preset %q produces invalid config: %v", name, err)
+		}
+		if len(diffConfigs(DefaultConfig, cfg)) == 0 {
+			t.Errorf("Preset %q does not differ from DefaultConfig", name)
+		}
+	}
+}
+
+func TestApplyPreset_FlatDiskFlattensArms(t *testing.T) {
+	cfg, err := ApplyPreset("flat-disk", DefaultConfig)
+	if err != nil {
+		t.Fatalf("ApplyPreset failed: %v", err)
+	}
+
+	// Several systems per arm, so most sit away from the arm start where
+	// the wave vanishes
+	tiers := map[string]TierInfo{}
+	for i := 0; i < 15; i++ {
+		sw := fmt.Sprintf("A%d", i)
+		tiers[sw] = TierInfo{Tier: "A", InstanceCount: 1000 - i, Software: sw}
+	}
+	height := func(cfg Config) float64 {
+		total := 0.0
+		for _, p := range calculateSystemCenters(tiers, cfg) {
+			total += math.Abs(p.Z)
+		}
+		return total
+	}
+	if flat, def := height(cfg), height(DefaultConfig); flat >= def/2 {
+		t.Errorf("flat-disk systems should sit much closer to the plane: total |z| %.0f vs default %.0f", flat, def)
+	}
+}
+
+func TestApplyPreset_Unknown(t *testing.T) {
+	if _, err := ApplyPreset("no-such-look", DefaultConfig); err == nil {
+		t.Error("Expected error for unknown preset")
+	}
+}
+
+func TestApplyPreset_FileLayersOverPreset(t *testing.T) {
+	cfg, err := ApplyPreset("compact", DefaultConfig)
+	if err != nil {
+		t.Fatalf("ApplyPreset failed: %v", err)
+	}
+	path := writeConfigFile(t, "tweaks.yaml", "supergiant_radius: 2500\n")
+
+	cfg, err = LoadConfig(path, cfg)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.SupergiantRadius != 2500 {
+		t.Errorf("File should override preset: SupergiantRadius = %f, want 2500", cfg.SupergiantRadius)
+	}
+	if cfg.TierASystemMaxRadius != 2500 {
+		t.Errorf("Preset value should survive: TierASystemMaxRadius = %f, want 2500", cfg.TierASystemMaxRadius)
+	}
+	if cfg.HueYoung != DefaultConfig.HueYoung {
+		t.Errorf("Default should survive: HueYoung = %f", cfg.HueYoung)
+	}
+}
+
+func TestDiffConfigs_ReportsChangedKeys(t *testing.T) {
+	b := DefaultConfig.clone()
+	b.HueYoung = 200
+
+	diffs := diffConfigs(DefaultConfig, b)
+	if len(diffs) != 1 || diffs[0].Key != "hue_young" {
+		t.Errorf("Expected single hue_young diff, got %+v", diffs)
+	}
+}
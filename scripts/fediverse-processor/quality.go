@@ -0,0 +1,282 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// Timestamp fallback policies for instances whose creation date cannot be determined
+const (
+	TimestampFallbackSkip    = "skip"    // Drop the instance from the output
+	TimestampFallbackGenesis = "genesis" // Treat it as created on GenesisDate (oldest, red)
+	TimestampFallbackMedian  = "median"  // Use the median creation date of the dataset
+	TimestampFallbackNow     = "now"     // Treat it as created at the as-of time (youngest, blue)
+)
+
+var timestampFallbacks = []string{
+	TimestampFallbackSkip,
+	TimestampFallbackGenesis,
+	TimestampFallbackMedian,
+	TimestampFallbackNow,
+}
+
+// TimestampIssue records an instance whose creation date could not be determined
+type TimestampIssue struct {
+	Domain   string `json:"domain"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	Reason   string `json:"reason"`
+	Fallback string `json:"fallback"`
+}
+
+// Duplicate policies for records whose domains normalize to the same host
+const (
+	DuplicateFirst     = "first"      // Keep the first record
+	DuplicateLast      = "last"       // Keep the last record
+	DuplicateMostUsers = "most_users" // Keep the record with the largest user_count
+	DuplicateMerge     = "merge"      // Keep the first record, filling its empty fields (and peers) from the others
+)
+
+var duplicatePolicies = []string{
+	DuplicateFirst,
+	DuplicateLast,
+	DuplicateMostUsers,
+	DuplicateMerge,
+}
+
+// DuplicateGroup records several input records that were collapsed into one
+type DuplicateGroup struct {
+	Domain   string   `json:"domain"`
+	Variants []string `json:"variants"` // Domains as written in the input, in input order
+	Policy   string   `json:"policy"`
+}
+
+// ProcessReport collects data-quality findings from a processing run
+type ProcessReport struct {
+	TimestampFallback string
+	TimestampIssues   []TimestampIssue
+	DuplicatePolicy   string
+	Duplicates        []DuplicateGroup
+}
+
+// normalizeDomain reduces a domain to its canonical host form: trimmed,
+// lowercased, without a trailing dot, and with IDNs in their ASCII (punycode)
+// form. Names that are not valid IDNs are only lowercased.
+func normalizeDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		return ascii
+	}
+	return domain
+}
+
+// DedupeInstances collapses records whose domains normalize to the same host
+// according to policy. The output keeps the position of each host's first
+// record, and its domain as written in the kept record (see displayDomain).
+func DedupeInstances(instances []Instance, policy string) ([]Instance, []DuplicateGroup) {
+	var order []string
+	groups := make(map[string][]int)
+	for i := range instances {
+		domain := normalizeDomain(instances[i].Domain)
+		if _, seen := groups[domain]; !seen {
+			order = append(order, domain)
+		}
+		groups[domain] = append(groups[domain], i)
+	}
+
+	var duplicates []DuplicateGroup
+	result := make([]Instance, 0, len(order))
+	for _, domain := range order {
+		idx := groups[domain]
+		kept := instances[idx[0]]
+
+		if len(idx) > 1 {
+			group := DuplicateGroup{Domain: domain, Policy: policy}
+			for _, i := range idx {
+				group.Variants = append(group.Variants, instances[i].Domain)
+			}
+			duplicates = append(duplicates, group)
+
+			switch policy {
+			case DuplicateLast:
+				kept = instances[idx[len(idx)-1]]
+			case DuplicateMostUsers:
+				for _, i := range idx[1:] {
+					if userCount(&instances[i]) > userCount(&kept) {
+						kept = instances[i]
+					}
+				}
+			case DuplicateMerge:
+				// Copy the nested structs so filling them leaves the input untouched
+				if kept.Software != nil {
+					sw := *kept.Software
+					kept.Software = &sw
+				}
+				if kept.Stats != nil {
+					st := *kept.Stats
+					kept.Stats = &st
+				}
+				provenance := make(map[string]string, len(kept.Provenance))
+				for field, source := range kept.Provenance {
+					provenance[field] = source
+				}
+				for _, i := range idx[1:] {
+					other := &instances[i]
+					for _, field := range mergeFields {
+						if _, ok := field.get(&kept); ok {
+							continue
+						}
+						if _, ok := field.get(other); ok {
+							field.set(&kept, other)
+							// Credit the record's own source if it has one
+							source := other.Provenance[field.name]
+							if source == "" {
+								source = "duplicate:" + other.Domain
+							}
+							provenance[field.name] = source
+						}
+					}
+					mergeAttached(&kept, other)
+				}
+				if len(provenance) > 0 {
+					kept.Provenance = provenance
+				}
+			}
+		}
+
+		kept.Domain = displayDomain(kept.Domain)
+		result = append(result, kept)
+	}
+	return result, duplicates
+}
+
+// displayDomain tidies a domain for output without converting its IDN form:
+// trimmed, lowercased and without a trailing dot, so Unicode names stay
+// readable while case and dot variants still agree
+func displayDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// mergeAttached fills the data attached to kept from other files (peers,
+// nebula group, previous placement) from other, a duplicate record of the
+// same host. Peer lists are combined.
+func mergeAttached(kept, other *Instance) {
+	if len(other.Peers) > 0 {
+		seen := make(map[string]bool, len(kept.Peers))
+		peers := append([]string(nil), kept.Peers...)
+		for _, p := range peers {
+			seen[p] = true
+		}
+		for _, p := range other.Peers {
+			if !seen[p] {
+				seen[p] = true
+				peers = append(peers, p)
+			}
+		}
+		kept.Peers = peers
+	}
+	if kept.NebulaGroup == "" {
+		kept.NebulaGroup = other.NebulaGroup
+	}
+	if kept.Previous == nil {
+		kept.Previous = other.Previous
+	}
+}
+
+func userCount(inst *Instance) int {
+	if inst.Stats == nil {
+		return 0
+	}
+	return inst.Stats.UserCount
+}
+
+// instanceCreatedAt returns the creation timestamp used for coloring and the
+// field it was taken from
+func instanceCreatedAt(instance *Instance) (value, field string) {
+	if instance.CreationTime != nil && instance.CreationTime.CreatedAt != "" {
+		return instance.CreationTime.CreatedAt, "creation_time.created_at"
+	}
+	return instance.FirstSeenAt, "first_seen_at"
+}
+
+// ResolveCreationDates finds instances whose creation date is missing or
+// unparseable and applies cfg.TimestampFallback to them. Fallback dates are
+// written to CreationTime with Reliable=false so the output shows they were guessed.
+func ResolveCreationDates(instances []Instance, cfg Config) ([]Instance, []TimestampIssue) {
+	var issues []TimestampIssue
+	var valid []time.Time
+	bad := make([]bool, len(instances))
+
+	for i := range instances {
+		value, field := instanceCreatedAt(&instances[i])
+		if value == "" {
+			bad[i] = true
+			issues = append(issues, TimestampIssue{
+				Domain: instances[i].Domain, Field: field, Reason: "missing creation date",
+			})
+			continue
+		}
+		t, err := parseTimeStrict(value)
+		if err != nil {
+			bad[i] = true
+			issues = append(issues, TimestampIssue{
+				Domain: instances[i].Domain, Field: field, Value: value, Reason: err.Error(),
+			})
+			continue
+		}
+		valid = append(valid, t)
+	}
+
+	if len(issues) == 0 {
+		return instances, nil
+	}
+
+	policy := cfg.TimestampFallback
+	for i := range issues {
+		issues[i].Fallback = policy
+	}
+
+	var fallback string
+	switch policy {
+	case TimestampFallbackSkip:
+		result := make([]Instance, 0, len(instances)-len(issues))
+		for i := range instances {
+			if !bad[i] {
+				result = append(result, instances[i])
+			}
+		}
+		return result, issues
+	case TimestampFallbackGenesis:
+		fallback = cfg.GenesisDate
+	case TimestampFallbackMedian:
+		fallback = medianTime(valid, cfg.referenceTime()).Format(time.RFC3339)
+	default:
+		fallback = cfg.referenceTime().Format(time.RFC3339)
+	}
+
+	result := make([]Instance, len(instances))
+	copy(result, instances)
+	for i := range result {
+		if bad[i] {
+			result[i].CreationTime = &CreationTime{
+				CreatedAt: fallback,
+				Source:    "fallback:" + policy,
+				Reliable:  false,
+			}
+		}
+	}
+	return result, issues
+}
+
+// medianTime returns the median of times, or def if times is empty
+func medianTime(times []time.Time, def time.Time) time.Time {
+	if len(times) == 0 {
+		return def
+	}
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	return sorted[len(sorted)/2]
+}
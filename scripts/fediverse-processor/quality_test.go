@@ -0,0 +1,222 @@
+package main
+
+import (
+	"testing"
+)
+
+// ============================================================
+// A. Creation Date Resolution Tests
+// ============================================================
+
+func timestampFixture() []Instance {
+	return []Instance{
+		{Domain: "old.test", FirstSeenAt: "2018-01-01T00:00:00Z"},
+		{Domain: "mid.test", FirstSeenAt: "2021-01-01"},
+		{Domain: "new.test", CreationTime: &CreationTime{CreatedAt: "2024-01-01T00:00:00Z"}},
+		{Domain: "garbled.test", FirstSeenAt: "last tuesday"},
+		{Domain: "missing.test"},
+	}
+}
+
+func TestResolveCreationDates_ReportsIssues(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.AsOf = "2026-01-01T00:00:00Z"
+
+	result, issues := ResolveCreationDates(timestampFixture(), cfg)
+
+	if len(result) != 5 {
+		t.Errorf("Default policy should keep all instances, got %d", len(result))
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d: %+v", len(issues), issues)
+	}
+	if issues[0].Domain != "garbled.test" || issues[0].Value != "last tuesday" {
+		t.Errorf("Unexpected first issue: %+v", issues[0])
+	}
+	if issues[1].Domain != "missing.test" || issues[1].Reason != "missing creation date" {
+		t.Errorf("Unexpected second issue: %+v", issues[1])
+	}
+}
+
+func TestResolveCreationDates_Policies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{TimestampFallbackGenesis, DefaultConfig.GenesisDate},
+		{TimestampFallbackMedian, "2021-01-01T00:00:00Z"},
+		{TimestampFallbackNow, "2026-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig
+		cfg.AsOf = "2026-01-01T00:00:00Z"
+		cfg.TimestampFallback = tt.policy
+
+		result, _ := ResolveCreationDates(timestampFixture(), cfg)
+
+		for _, inst := range result[3:] {
+			if inst.CreationTime == nil || inst.CreationTime.CreatedAt != tt.want {
+				t.Errorf("%s: %s should fall back to %s, got %+v", tt.policy, inst.Domain, tt.want, inst.CreationTime)
+				continue
+			}
+			if inst.CreationTime.Reliable || inst.CreationTime.Source != "fallback:"+tt.policy {
+				t.Errorf("%s: fallback date should be marked unreliable, got %+v", tt.policy, inst.CreationTime)
+			}
+		}
+		if result[0].CreationTime != nil {
+			t.Errorf("%s: valid instances should not be modified", tt.policy)
+		}
+	}
+}
+
+func TestResolveCreationDates_Skip(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.TimestampFallback = TimestampFallbackSkip
+
+	result, issues := ResolveCreationDates(timestampFixture(), cfg)
+
+	if len(result) != 3 || len(issues) != 2 {
+		t.Errorf("Skip should drop 2 instances, got %d kept, %d issues", len(result), len(issues))
+	}
+	for _, inst := range result {
+		if inst.Domain == "garbled.test" || inst.Domain == "missing.test" {
+			t.Errorf("Skip policy kept %s", inst.Domain)
+		}
+	}
+}
+
+func TestValidate_TimestampFallback(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.TimestampFallback = "guess"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown timestamp_fallback")
+	}
+}
+
+// ============================================================
+// B. Domain Normalization and Deduplication Tests
+// ============================================================
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Mastodon.Social", "mastodon.social"},
+		{" mastodon.social. ", "mastodon.social"},
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"BÜCHER.example", "xn--bcher-kva.example"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"under_score.example", "under_score.example"},
+	}
+	for _, tt := range tests {
+		if got := normalizeDomain(tt.in); got != tt.want {
+			t.Errorf("normalizeDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func duplicateFixture() []Instance {
+	return []Instance{
+		{Domain: "Mastodon.Social", Name: "Mastodon", Stats: &Stats{UserCount: 100}},
+		{Domain: "bücher.example", Stats: &Stats{UserCount: 5}},
+		{Domain: "mastodon.social.", Description: "Flagship", Stats: &Stats{UserCount: 300}},
+		{Domain: "xn--bcher-kva.example", Software: &Software{Name: "Mastodon"}},
+		{Domain: "solo.example"},
+	}
+}
+
+func TestDedupeInstances_ReportsGroups(t *testing.T) {
+	result, groups := DedupeInstances(duplicateFixture(), DuplicateMerge)
+
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 instances, got %d", len(result))
+	}
+	want := []string{"mastodon.social", "bücher.example", "solo.example"}
+	for i, domain := range want {
+		if result[i].Domain != domain {
+			t.Errorf("result[%d].Domain = %q, want %q", i, result[i].Domain, domain)
+		}
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 duplicate groups, got %+v", groups)
+	}
+	if groups[0].Domain != "mastodon.social" || len(groups[0].Variants) != 2 ||
+		groups[0].Variants[1] != "mastodon.social." || groups[0].Policy != DuplicateMerge {
+		t.Errorf("Unexpected first group: %+v", groups[0])
+	}
+}
+
+func TestDedupeInstances_Policies(t *testing.T) {
+	tests := []struct {
+		policy      string
+		users       int
+		description string
+	}{
+		{DuplicateFirst, 100, ""},
+		{DuplicateLast, 300, "Flagship"},
+		{DuplicateMostUsers, 300, "Flagship"},
+		{DuplicateMerge, 100, "Flagship"},
+	}
+	for _, tt := range tests {
+		input := duplicateFixture()
+		result, _ := DedupeInstances(input, tt.policy)
+		ms := result[0]
+		if ms.Stats.UserCount != tt.users || ms.Description != tt.description {
+			t.Errorf("%s: got users=%d description=%q, want %d %q",
+				tt.policy, ms.Stats.UserCount, ms.Description, tt.users, tt.description)
+		}
+		if input[0].Domain != "Mastodon.Social" || input[0].Description != "" {
+			t.Errorf("%s: input was modified: %+v", tt.policy, input[0])
+		}
+	}
+
+	// merge fills nested fields without touching the input's pointers
+	input := duplicateFixture()
+	result, _ := DedupeInstances(input, DuplicateMerge)
+	if result[1].Software == nil || result[1].Software.Name != "Mastodon" || input[1].Software != nil {
+		t.Errorf("Expected software filled from the punycode record, got %+v", result[1].Software)
+	}
+}
+
+func TestDedupeInstances_MergeKeepsAttachedDataAndProvenance(t *testing.T) {
+	prev := &Placement{Software: "Mastodon", PositionType: "planet"}
+	input := []Instance{
+		{Domain: "Social.Example", Peers: []string{"a.example"}},
+		{
+			Domain:      "social.example.",
+			Name:        "Social",
+			Stats:       &Stats{UserCount: 10},
+			Provenance:  map[string]string{"stats.user_count": "fedidb"},
+			Peers:       []string{"a.example", "b.example"},
+			NebulaGroup: "Example",
+			Previous:    prev,
+		},
+	}
+	result, _ := DedupeInstances(input, DuplicateMerge)
+	if len(result) != 1 {
+		t.Fatalf("Expected one instance, got %d", len(result))
+	}
+	got := result[0]
+	if got.Domain != "social.example" {
+		t.Errorf("Domain = %q, want social.example", got.Domain)
+	}
+	if len(got.Peers) != 2 || got.Peers[1] != "b.example" || got.NebulaGroup != "Example" || got.Previous != prev {
+		t.Errorf("Attached data not merged: peers=%v nebula=%q previous=%v", got.Peers, got.NebulaGroup, got.Previous)
+	}
+	if got.Provenance["stats.user_count"] != "fedidb" || got.Provenance["name"] != "duplicate:social.example." {
+		t.Errorf("Provenance should record the merge, got %v", got.Provenance)
+	}
+	if input[0].Provenance != nil || len(input[0].Peers) != 1 {
+		t.Errorf("Input was modified: %+v", input[0])
+	}
+}
+
+func TestValidate_DuplicatePolicy(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.DuplicatePolicy = "newest"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown duplicate_policy")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+)
+
+// ============================================================================
+// Collision Resolution
+// ============================================================================
+
+// positionTypes lists every PositionType the built-in layouts assign
+var positionTypes = []string{"supergiant", "planet", "asteroid", "satellite", "dust", "unknown"}
+
+// separationScale returns how much room an instance of positionType needs,
+// relative to separation.min_distance (1 for types not in the map)
+func separationScale(cfg SeparationConfig, positionType string) float64 {
+	if s, ok := cfg.TypeScale[positionType]; ok {
+		return s
+	}
+	return 1
+}
+
+type cellKey struct{ x, y, z int }
+
+// ResolveCollisions pushes apart placed instances closer than their minimum
+// separation: min_distance times the mean type scale of the pair. Supergiants
+// keep their configured positions, and anchored instances their previous
+// ones. Each pass finds overlapping pairs with a spatial hash and moves both
+// halves of the pair apart; passes repeat until no pair overlaps or
+// separation.iterations is reached. It returns the number of overlapping
+// pairs found in the first pass.
+func ResolveCollisions(instances []Instance, cfg SeparationConfig) int {
+	if cfg.MinDistance <= 0 || cfg.Iterations <= 0 {
+		return 0
+	}
+
+	var idx []int
+	var radius []float64
+	maxScale := 0.0
+	for i := range instances {
+		if instances[i].Position == nil {
+			continue
+		}
+		s := separationScale(cfg, instances[i].PositionType)
+		idx = append(idx, i)
+		radius = append(radius, s*cfg.MinDistance/2)
+		maxScale = math.Max(maxScale, s)
+	}
+	n := len(idx)
+	if n < 2 || maxScale <= 0 {
+		return 0
+	}
+
+	// No pair can need more room than the two largest radii, so checking the
+	// 27 cells around a point finds every overlap
+	cell := maxScale * cfg.MinDistance
+	pinned := make([]bool, n)
+	for k, i := range idx {
+		pinned[k] = instances[i].PositionType == "supergiant" || instances[i].Anchored
+	}
+	px, py, pz := make([]float64, n), make([]float64, n), make([]float64, n)
+	for k, i := range idx {
+		p := instances[i].Position
+		px[k], py[k], pz[k] = p.X, p.Y, p.Z
+	}
+	dx, dy, dz := make([]float64, n), make([]float64, n), make([]float64, n)
+
+	firstPass := 0
+	for pass := 0; pass < cfg.Iterations; pass++ {
+		grid := make(map[cellKey][]int, n)
+		keys := make([]cellKey, n)
+		for k := 0; k < n; k++ {
+			keys[k] = cellKey{int(math.Floor(px[k] / cell)), int(math.Floor(py[k] / cell)), int(math.Floor(pz[k] / cell))}
+			grid[keys[k]] = append(grid[keys[k]], k)
+		}
+		for k := range dx {
+			dx[k], dy[k], dz[k] = 0, 0, 0
+		}
+
+		overlaps := 0
+		for a := 0; a < n; a++ {
+			ka := keys[a]
+			for ox := -1; ox <= 1; ox++ {
+				for oy := -1; oy <= 1; oy++ {
+					for oz := -1; oz <= 1; oz++ {
+						for _, b := range grid[cellKey{ka.x + ox, ka.y + oy, ka.z + oz}] {
+							if b <= a || (pinned[a] && pinned[b]) {
+								continue
+							}
+							need := radius[a] + radius[b]
+							ex, ey, ez := px[b]-px[a], py[b]-py[a], pz[b]-pz[a]
+							d := math.Sqrt(ex*ex + ey*ey + ez*ez)
+							if d >= need {
+								continue
+							}
+							overlaps++
+
+							// Unit vector from a to b
+							if d < 1e-9 {
+								ex, ey, ez = collisionDirection(instances[idx[a]].Domain, instances[idx[b]].Domain)
+							} else {
+								ex, ey, ez = ex/d, ey/d, ez/d
+							}
+
+							// Split the overlap between the pair; a pinned side does not move
+							wa, wb := 0.5, 0.5
+							if pinned[a] {
+								wa, wb = 0, 1
+							} else if pinned[b] {
+								wa, wb = 1, 0
+							}
+							push := need - d
+							dx[a] -= ex * push * wa
+							dy[a] -= ey * push * wa
+							dz[a] -= ez * push * wa
+							dx[b] += ex * push * wb
+							dy[b] += ey * push * wb
+							dz[b] += ez * push * wb
+						}
+					}
+				}
+			}
+		}
+
+		if pass == 0 {
+			firstPass = overlaps
+		}
+		if overlaps == 0 {
+			break
+		}
+		for k := 0; k < n; k++ {
+			px[k] += dx[k]
+			py[k] += dy[k]
+			pz[k] += dz[k]
+		}
+	}
+
+	for k, i := range idx {
+		if pinned[k] {
+			continue
+		}
+		instances[i].Position = &Position{
+			X: math.Round(px[k]*10) / 10,
+			Y: math.Round(py[k]*10) / 10,
+			Z: math.Round(pz[k]*10) / 10,
+		}
+	}
+	return firstPass
+}
+
+// collisionDirection returns a unit vector that depends only on the two
+// domains, so coincident instances separate the same way on every run
+func collisionDirection(a, b string) (x, y, z float64) {
+	theta := domainHash(a+"|"+b+"_theta") * 2 * math.Pi
+	phi := math.Acos(2*domainHash(a+"|"+b+"_phi") - 1)
+	return math.Sin(phi) * math.Cos(theta), math.Sin(phi) * math.Sin(theta), math.Cos(phi)
+}
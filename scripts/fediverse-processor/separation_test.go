@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// ============================================================
+// A. Collision Resolution Tests
+// ============================================================
+
+func separationTestConfig() SeparationConfig {
+	return SeparationConfig{
+		MinDistance: 10,
+		Iterations:  50,
+		TypeScale:   map[string]float64{"supergiant": 4, "planet": 2, "dust": 1},
+	}
+}
+
+func distance(a, b *Position) float64 {
+	return math.Sqrt((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y) + (a.Z-b.Z)*(a.Z-b.Z))
+}
+
+// crowdedFixture packs many dust instances into a ball a few units across
+func crowdedFixture() []Instance {
+	var instances []Instance
+	for i := 0; i < 60; i++ {
+		d := fmt.Sprintf("n%d.test", i)
+		instances = append(instances, Instance{
+			Domain:       d,
+			PositionType: "dust",
+			Position: &Position{
+				X: domainHash(d+"_x") * 5,
+				Y: domainHash(d+"_y") * 5,
+				Z: domainHash(d+"_z") * 5,
+			},
+		})
+	}
+	return instances
+}
+
+func TestResolveCollisions_EnforcesMinimumDistance(t *testing.T) {
+	cfg := separationTestConfig()
+	instances := crowdedFixture()
+	if n := ResolveCollisions(instances, cfg); n == 0 {
+		t.Fatal("Expected overlaps in the crowded fixture")
+	}
+
+	// Allow for rounding to 0.1
+	for i := range instances {
+		for j := i + 1; j < len(instances); j++ {
+			if d := distance(instances[i].Position, instances[j].Position); d < cfg.MinDistance-0.2 {
+				t.Fatalf("%s and %s are %.2f apart, want at least %g", instances[i].Domain, instances[j].Domain, d, cfg.MinDistance)
+			}
+		}
+	}
+
+	if n := ResolveCollisions(instances, cfg); n != 0 {
+		t.Errorf("A resolved layout should have no overlaps left, got %d", n)
+	}
+}
+
+func TestResolveCollisions_ScalesByType(t *testing.T) {
+	cfg := separationTestConfig()
+	instances := []Instance{
+		{Domain: "a.test", PositionType: "planet", Position: &Position{}},
+		{Domain: "b.test", PositionType: "planet", Position: &Position{X: 5}},
+		{Domain: "c.test", PositionType: "dust", Position: &Position{X: 500}},
+		{Domain: "d.test", PositionType: "dust", Position: &Position{X: 505}},
+	}
+	ResolveCollisions(instances, cfg)
+
+	if d := distance(instances[0].Position, instances[1].Position); math.Abs(d-20) > 0.2 {
+		t.Errorf("Planets should end 20 apart (2x min distance), got %.2f", d)
+	}
+	if d := distance(instances[2].Position, instances[3].Position); math.Abs(d-10) > 0.2 {
+		t.Errorf("Dust should end 10 apart, got %.2f", d)
+	}
+	// Both moved the same amount along the line between them
+	if instances[0].Position.X != -7.5 || instances[1].Position.X != 12.5 {
+		t.Errorf("Expected the overlap split evenly, got %v and %v", instances[0].Position, instances[1].Position)
+	}
+}
+
+func TestResolveCollisions_SupergiantsStayPut(t *testing.T) {
+	cfg := separationTestConfig()
+	instances := []Instance{
+		{Domain: "big.test", PositionType: "supergiant", Position: &Position{X: 100, Y: 100}},
+		{Domain: "small.test", PositionType: "dust", Position: &Position{X: 101, Y: 100}},
+	}
+	ResolveCollisions(instances, cfg)
+
+	if *instances[0].Position != (Position{X: 100, Y: 100}) {
+		t.Errorf("Supergiant moved to %v", instances[0].Position)
+	}
+	// Mean of scales 4 and 1 times 10
+	if d := distance(instances[0].Position, instances[1].Position); math.Abs(d-25) > 0.2 {
+		t.Errorf("Expected dust pushed 25 from the supergiant, got %.2f", d)
+	}
+}
+
+func TestResolveCollisions_CoincidentPointsDeterministic(t *testing.T) {
+	cfg := separationTestConfig()
+	fixture := func() []Instance {
+		return []Instance{
+			{Domain: "one.test", PositionType: "dust", Position: &Position{X: 3, Y: 3, Z: 3}},
+			{Domain: "two.test", PositionType: "dust", Position: &Position{X: 3, Y: 3, Z: 3}},
+			{Domain: "three.test", PositionType: "dust", Position: &Position{X: 3, Y: 3, Z: 3}},
+		}
+	}
+	a, b := fixture(), fixture()
+	ResolveCollisions(a, cfg)
+	ResolveCollisions(b, cfg)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Repeated runs should place coincident instances identically")
+	}
+	if *a[0].Position == *a[1].Position || *a[1].Position == *a[2].Position {
+		t.Error("Coincident instances should be separated")
+	}
+}
+
+func TestResolveCollisions_Disabled(t *testing.T) {
+	cfg := separationTestConfig()
+	cfg.MinDistance = 0
+	instances := crowdedFixture()
+	want := crowdedFixture()
+	if n := ResolveCollisions(instances, cfg); n != 0 || !reflect.DeepEqual(instances, want) {
+		t.Error("min_distance 0 should leave positions untouched")
+	}
+}
+
+func TestValidate_Separation(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Separation.MinDistance = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a negative separation.min_distance")
+	}
+
+	cfg = DefaultConfig.clone()
+	cfg.Separation.TypeScale["comet"] = 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for an unknown position type in separation.type_scale")
+	}
+	if _, ok := DefaultConfig.Separation.TypeScale["comet"]; ok {
+		t.Error("clone should not share type_scale with DefaultConfig")
+	}
+
+	cfg = DefaultConfig.clone()
+	cfg.Separation.TypeScale["dust"] = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a zero type scale")
+	}
+}
+
+func TestLoadConfig_TypeScaleReplacesDefaults(t *testing.T) {
+	path := writeConfigFile(t, "scale.yaml", "separation:\n  type_scale:\n    dust: 2\n")
+	cfg, err := LoadConfig(path, DefaultConfig)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.Separation.TypeScale) != 1 || cfg.Separation.TypeScale["dust"] != 2 {
+		t.Errorf("A file's type_scale should replace the defaults, got %v", cfg.Separation.TypeScale)
+	}
+	if len(DefaultConfig.Separation.TypeScale) == 1 {
+		t.Error("LoadConfig modified DefaultConfig")
+	}
+}
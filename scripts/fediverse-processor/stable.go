@@ -0,0 +1,187 @@
+package main
+
+import (
+	"math"
+)
+
+// ============================================================================
+// Stable Placement
+// ============================================================================
+
+// Placement is where an earlier run put an instance
+type Placement struct {
+	Software     string
+	PositionType string
+	Position     Position
+	Nebula       string         // Nebula named for an unknown-software instance, if any
+	SystemSizes  map[string]int // Members per software system in the earlier run, shared by all placements
+}
+
+// AttachPrevious copies each instance's placement from an earlier output onto
+// the instance and returns how many instances were found there
+func AttachPrevious(instances []Instance, previous []Instance) int {
+	sizes := make(map[string]int)
+	for i := range previous {
+		if software := getSoftwareName(&previous[i]); software != "Unknown" {
+			sizes[software]++
+		}
+	}
+
+	byDomain := make(map[string]*Placement, len(previous))
+	for i := range previous {
+		p := &previous[i]
+		if p.Position == nil {
+			continue
+		}
+		byDomain[normalizeDomain(p.Domain)] = &Placement{
+			Software:     getSoftwareName(p),
+			PositionType: p.PositionType,
+			Position:     *p.Position,
+			Nebula:       p.Nebula,
+			SystemSizes:  sizes,
+		}
+	}
+
+	matched := 0
+	for i := range instances {
+		if p, ok := byDomain[normalizeDomain(instances[i].Domain)]; ok {
+			instances[i].Previous = p
+			matched++
+		}
+	}
+	return matched
+}
+
+// keepsPlacement reports whether an instance may stay where the previous run
+// put it: it must still belong to the same software system and size class
+func keepsPlacement(instance *Instance, software, sizeType string) bool {
+	p := instance.Previous
+	return p != nil && p.Software == software && p.PositionType == sizeType
+}
+
+// previousCenters recomputes the system centers of the previous run from its
+// system sizes. A system joining, leaving or changing tier shifts the others,
+// so kept members are carried along by the difference to the current center.
+func previousCenters(
+	instances []Instance,
+	centers func(softwareTiers map[string]TierInfo, cfg Config) map[string]Position,
+	cfg Config,
+) map[string]Position {
+	var sizes map[string]int
+	for i := range instances {
+		if p := instances[i].Previous; p != nil {
+			sizes = p.SystemSizes
+			break
+		}
+	}
+	if sizes == nil {
+		return nil
+	}
+
+	tiers := make(map[string]TierInfo, len(sizes))
+	for software, n := range sizes {
+		tiers[software] = TierInfo{Tier: calculateSystemTier(n, cfg), InstanceCount: n, Software: software}
+	}
+	return centers(tiers, cfg)
+}
+
+// keptPosition moves a previous position by the shift of its system center,
+// so the member keeps its place relative to the rest of the system
+func keptPosition(previous, previousCenter, center Position) *Position {
+	return &Position{
+		X: math.Round((center.X+previous.X-previousCenter.X)*10) / 10,
+		Y: math.Round((center.Y+previous.Y-previousCenter.Y)*10) / 10,
+		Z: math.Round((center.Z+previous.Z-previousCenter.Z)*10) / 10,
+	}
+}
+
+// assignSlots gives the newcomers of one system (indices, sorted by rank)
+// their lattice slots; kept members stay at kept[idx] and take no slot. In
+// rank order, each newcomer takes the first free slot whose position keeps
+// clear of everyone placed so far, or failing that the free slot with the
+// most room. Slots are judged only by the positions they produce, since a
+// lattice sized to a different member total says nothing about where kept
+// members sit.
+func assignSlots(instances []Instance, indices []int, kept []*Position, center Position, systemMaxRadius float64, software string, cfg Config, slot []int) {
+	total := len(indices)
+
+	// Members of a size class share a shell, so the clearance is half the
+	// mean spacing of total points on the candidate's shell, measured no
+	// further in than the innermost (planet) shell
+	spacing := 0.5 * math.Sqrt(4*math.Pi/float64(total))
+	innermost, _ := getInstanceRadiusRange("planet")
+	placed := newPointGrid(spacing * systemMaxRadius)
+	for _, idx := range indices {
+		if kept[idx] != nil {
+			placed.add(*kept[idx])
+		}
+	}
+
+	taken := make([]bool, total)
+	for _, idx := range indices {
+		if kept[idx] != nil {
+			continue
+		}
+		best, bestRoom := -1, -1.0
+		var bestPos *Position
+		for s := 0; s < total; s++ {
+			if taken[s] {
+				continue
+			}
+			pos := systemSlotPosition(&instances[idx], center, systemMaxRadius, s, total, software, cfg)
+			ox, oy, oz := pos.X-center.X, pos.Y-center.Y, pos.Z-center.Z
+			shell := math.Max(math.Sqrt(ox*ox+oy*oy+oz*oz), innermost*systemMaxRadius)
+			clearance := math.Min(spacing*shell, placed.cell)
+			room := placed.room(*pos, clearance) / clearance
+			if room > bestRoom {
+				best, bestRoom, bestPos = s, room, pos
+			}
+			if room >= 1 {
+				break
+			}
+		}
+		slot[idx] = best
+		taken[best] = true
+		placed.add(*bestPos)
+	}
+}
+
+// pointGrid is a spatial hash of placed positions with cells as wide as the
+// clearance, so a clearance query only visits the neighbouring cells
+type pointGrid struct {
+	cell  float64
+	cells map[cellKey][]Position
+}
+
+func newPointGrid(clearance float64) *pointGrid {
+	return &pointGrid{cell: clearance, cells: make(map[cellKey][]Position)}
+}
+
+func (g *pointGrid) key(p Position) cellKey {
+	return cellKey{int(math.Floor(p.X / g.cell)), int(math.Floor(p.Y / g.cell)), int(math.Floor(p.Z / g.cell))}
+}
+
+func (g *pointGrid) add(p Position) {
+	k := g.key(p)
+	g.cells[k] = append(g.cells[k], p)
+}
+
+// room returns the distance from p to the nearest placed position, capped at
+// limit (at most the cell width)
+func (g *pointGrid) room(p Position, limit float64) float64 {
+	nearest := limit
+	k := g.key(p)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dz := -1; dz <= 1; dz++ {
+				for _, q := range g.cells[cellKey{k.x + dx, k.y + dy, k.z + dz}] {
+					ox, oy, oz := p.X-q.X, p.Y-q.Y, p.Z-q.Z
+					if d := math.Sqrt(ox*ox + oy*oy + oz*oz); d < nearest {
+						nearest = d
+					}
+				}
+			}
+		}
+	}
+	return nearest
+}
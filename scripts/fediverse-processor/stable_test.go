@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Stable Placement Tests
+// ============================================================
+
+// stableFixture has one software system of n members with distinct user
+// counts, from planets down to satellites
+func stableFixture(n int) []Instance {
+	var instances []Instance
+	for i := 0; i < n; i++ {
+		instances = append(instances, Instance{
+			Domain:   fmt.Sprintf("m%d.stable.test", i),
+			Software: &Software{Name: "Stable"},
+			Stats:    &Stats{UserCount: 3000 - i*29},
+		})
+	}
+	return instances
+}
+
+// placeStable places instances with their placement from previous attached
+func placeStable(instances, previous []Instance, cfg Config) []Instance {
+	in := append([]Instance(nil), instances...)
+	AttachPrevious(in, previous)
+	return ProcessPositions(in, cfg)
+}
+
+func byDomain(instances []Instance) map[string]*Instance {
+	m := make(map[string]*Instance, len(instances))
+	for i := range instances {
+		m[instances[i].Domain] = &instances[i]
+	}
+	return m
+}
+
+func TestStablePlacement_ChurnKeepsPositions(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	base := append(stableFixture(100), layoutFixture()...)
+	first := ProcessPositions(base, cfg)
+
+	// Without stable placement, dropping one member reshuffles the system
+	churned := append(append([]Instance(nil), base[:10]...), base[11:]...)
+	fresh := byDomain(ProcessPositions(churned, cfg))
+	moved := 0
+	for _, inst := range first {
+		if f, ok := fresh[inst.Domain]; ok && *f.Position != *inst.Position {
+			moved++
+		}
+	}
+	if moved < 50 {
+		t.Fatalf("Expected a fresh placement to move most members, moved %d", moved)
+	}
+
+	// With it, every remaining instance stays put and newcomers fit in
+	newcomers := stableFixture(103)[100:]
+	for i := range newcomers {
+		newcomers[i].Domain = fmt.Sprintf("new%d.stable.test", i)
+	}
+	stable := placeStable(append(churned, newcomers...), first, cfg)
+	prev := byDomain(first)
+	for i := range stable {
+		inst := &stable[i]
+		p, ok := prev[inst.Domain]
+		if !ok {
+			if inst.Anchored || inst.Position == nil {
+				t.Errorf("Newcomer %s should be placed afresh", inst.Domain)
+			}
+			continue
+		}
+		if *inst.Position != *p.Position {
+			t.Errorf("%s moved from %+v to %+v", inst.Domain, p.Position, inst.Position)
+		}
+	}
+}
+
+func TestStablePlacement_SizeClassChangeMoves(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	instances := stableFixture(40)
+	first := ProcessPositions(instances, cfg)
+
+	shrunk := append([]Instance(nil), instances...)
+	shrunk[30].Stats = &Stats{UserCount: 50}
+	stable := placeStable(shrunk, first, cfg)
+
+	if first[30].PositionType != "planet" || stable[30].PositionType != "satellite" {
+		t.Fatalf("Expected planet to become satellite, got %s and %s", first[30].PositionType, stable[30].PositionType)
+	}
+	if stable[30].Anchored || *stable[30].Position == *first[30].Position {
+		t.Errorf("An instance that changed size class should move, stayed at %+v", stable[30].Position)
+	}
+	if !stable[29].Anchored || *stable[29].Position != *first[29].Position {
+		t.Errorf("Unchanged neighbour should stay, moved to %+v", stable[29].Position)
+	}
+}
+
+func TestStablePlacement_SoftwareChangeMoves(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	instances := append(stableFixture(30), layoutFixture()...)
+	first := ProcessPositions(instances, cfg)
+
+	switched := append([]Instance(nil), instances...)
+	switched[5].Software = &Software{Name: "Other"}
+	stable := placeStable(switched, first, cfg)
+	if stable[5].Anchored {
+		t.Error("An instance that changed software should be placed in its new system")
+	}
+}
+
+// offsets returns each instance's position relative to the first instance
+// of its software, by domain
+func offsets(instances []Instance) map[string]Position {
+	first := make(map[string]Position)
+	rel := make(map[string]Position)
+	for _, inst := range instances {
+		sw := getSoftwareName(&inst)
+		if _, ok := first[sw]; !ok {
+			first[sw] = *inst.Position
+		}
+		f := first[sw]
+		rel[inst.Domain] = Position{X: inst.Position.X - f.X, Y: inst.Position.Y - f.Y, Z: inst.Position.Z - f.Z}
+	}
+	return rel
+}
+
+func TestStablePlacement_NewTierCSystemKeepsLayout(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	var instances []Instance
+	for s := 0; s < 4; s++ {
+		for i := 0; i < 6; i++ {
+			instances = append(instances, Instance{
+				Domain:   fmt.Sprintf("m%d.c%d.test", i, s),
+				Software: &Software{Name: fmt.Sprintf("Small%d", s)},
+				Stats:    &Stats{UserCount: 900 - i*100},
+			})
+		}
+	}
+	first := ProcessPositions(instances, cfg)
+
+	// A fifth tier C system re-spaces every tier C center
+	added := append(append([]Instance(nil), instances...), Instance{
+		Domain: "only.new.test", Software: &Software{Name: "Newcomer"}, Stats: &Stats{UserCount: 50},
+	})
+	stable := placeStable(added, first, cfg)
+
+	want := offsets(first)
+	got := offsets(stable[:len(instances)])
+	moved := 0
+	for i, inst := range stable[:len(instances)] {
+		if !inst.Anchored {
+			t.Errorf("%s should be kept", inst.Domain)
+		}
+		if *inst.Position != *first[i].Position {
+			moved++
+		}
+		w, g := want[inst.Domain], got[inst.Domain]
+		if math.Abs(w.X-g.X) > 0.2 || math.Abs(w.Y-g.Y) > 0.2 || math.Abs(w.Z-g.Z) > 0.2 {
+			t.Errorf("%s changed its place in its system: %+v -> %+v", inst.Domain, w, g)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("Expected the tier C systems to move with their centers")
+	}
+}
+
+func TestStablePlacement_NewcomersKeepClearOfKeptMembers(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	first := ProcessPositions(stableFixture(12), cfg)
+
+	// The system triples: none of the old lattice matches the new one
+	grown := stableFixture(40)
+	for i := 12; i < len(grown); i++ {
+		grown[i].Domain = fmt.Sprintf("new%d.stable.test", i)
+	}
+	stable := placeStable(grown, first, cfg)
+
+	nearest := math.Inf(1)
+	for _, n := range stable[12:] {
+		for _, k := range stable[:12] {
+			if !k.Anchored {
+				t.Fatalf("%s should be kept", k.Domain)
+			}
+			nearest = math.Min(nearest, distance(n.Position, k.Position))
+		}
+	}
+	// At least the clearance on the innermost (planet) shell
+	radius := calculateSystemMaxRadius(40, calculateSystemTier(40, cfg), cfg)
+	innermost, _ := getInstanceRadiusRange("planet")
+	if clearance := 0.5 * math.Sqrt(4*math.Pi/40) * innermost * radius; nearest < clearance {
+		t.Errorf("A newcomer landed %.1f from a kept member, want at least %.1f", nearest, clearance)
+	}
+}
+
+func TestStablePlacement_UnknownKeepsNebula(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Dust.Weights = map[string]float64{"nebula": 1}
+	instances := []Instance{{Domain: "a.dust.test"}, {Domain: "b.dust.test"}}
+	first := ProcessPositions(instances, cfg)
+
+	// The kept position stays labelled with the nebula it lies in, even
+	// though this run would pick a different strategy
+	cfg.Dust.Weights = map[string]float64{"halo": 1}
+	for i, inst := range placeStable(instances, first, cfg) {
+		if !inst.Anchored || *inst.Position != *first[i].Position {
+			t.Errorf("%s should keep its position", inst.Domain)
+		}
+		if inst.Nebula != "dust.test" || inst.DustStrategy != "" {
+			t.Errorf("%s: nebula %q strategy %q, want the previous nebula and no strategy", inst.Domain, inst.Nebula, inst.DustStrategy)
+		}
+	}
+}
+
+func TestValidate_Previous(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Previous = "final.json"
+	cfg.Layout = "force"
+	cfg.Force.Peers = "peers"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "previous") {
+		t.Errorf("Expected previous to be rejected with the force layout, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ============================================================================
+// Octree Tiling
+// ============================================================================
+
+// TilesetVersion is bumped whenever the manifest layout changes incompatibly
+const TilesetVersion = 1
+
+// TileOptions controls how instances are partitioned into tiles
+type TileOptions struct {
+	MaxPerTile int // Instances kept in a tile before the rest move to its children
+	MaxDepth   int // Deepest level; tiles at this level keep everything left
+}
+
+// DefaultTileOptions suits the ~40k-instance dataset: the root tile alone
+// carries every supergiant and planet
+var DefaultTileOptions = TileOptions{MaxPerTile: 2000, MaxDepth: 6}
+
+// TileBounds is an axis-aligned box
+type TileBounds struct {
+	Min [3]float64 `json:"min"`
+	Max [3]float64 `json:"max"`
+}
+
+func (b TileBounds) center() [3]float64 {
+	return [3]float64{(b.Min[0] + b.Max[0]) / 2, (b.Min[1] + b.Max[1]) / 2, (b.Min[2] + b.Max[2]) / 2}
+}
+
+// octant returns the child box with the given index (bit 0 = +x, 1 = +y, 2 = +z)
+func (b TileBounds) octant(i int) TileBounds {
+	c := b.center()
+	child := b
+	for axis := 0; axis < 3; axis++ {
+		if i&(1<<axis) != 0 {
+			child.Min[axis] = c[axis]
+		} else {
+			child.Max[axis] = c[axis]
+		}
+	}
+	return child
+}
+
+// TileNode is one tile in the manifest. A renderer draws a node's content and
+// descends into its children as the camera approaches its bounds.
+type TileNode struct {
+	ID       string         `json:"id"`    // Octree address: "r", then one octant digit per level
+	Level    int            `json:"level"` // Depth below the root
+	Bounds   TileBounds     `json:"bounds"`
+	Count    int            `json:"count"` // Instances in this tile
+	Total    int            `json:"total"` // Instances in this tile and all descendants
+	Types    map[string]int `json:"types"` // Instances in this tile by position type
+	Content  string         `json:"content,omitempty"`
+	Children []*TileNode    `json:"children,omitempty"`
+
+	instances []Instance
+}
+
+// Tileset is the manifest written as tileset.json
+type Tileset struct {
+	Version    int       `json:"version"`
+	Format     string    `json:"format"`
+	Total      int       `json:"total"`
+	MaxPerTile int       `json:"max_per_tile"`
+	Root       *TileNode `json:"root"`
+}
+
+// lodPriority orders position types from most to least prominent; prominent
+// instances are kept in the shallow tiles that load first
+func lodPriority(positionType string) int {
+	switch positionType {
+	case "supergiant":
+		return 0
+	case "planet":
+		return 1
+	case "asteroid":
+		return 2
+	case "satellite":
+		return 3
+	case "dust":
+		return 4
+	}
+	return 5
+}
+
+// BuildOctree partitions positioned instances into an octree of tiles.
+// Instances without a position are left out.
+func BuildOctree(instances []Instance, opts TileOptions) *TileNode {
+	var placed []Instance
+	for i := range instances {
+		if instances[i].Position != nil {
+			placed = append(placed, instances[i])
+		}
+	}
+
+	// Most prominent first; ties broken by size, then domain, for stable tiles
+	sort.SliceStable(placed, func(i, j int) bool {
+		pi, pj := lodPriority(placed[i].PositionType), lodPriority(placed[j].PositionType)
+		if pi != pj {
+			return pi < pj
+		}
+		if ui, uj := userCount(&placed[i]), userCount(&placed[j]); ui != uj {
+			return ui > uj
+		}
+		return placed[i].Domain < placed[j].Domain
+	})
+
+	root := &TileNode{ID: "r", Bounds: cubeBounds(placed)}
+	fillTile(root, placed, opts)
+	return root
+}
+
+// fillTile keeps the first MaxPerTile instances (already in priority order)
+// and hands the rest to the child octants
+func fillTile(node *TileNode, instances []Instance, opts TileOptions) {
+	node.Total = len(instances)
+	keep := len(instances)
+	if node.Level < opts.MaxDepth && opts.MaxPerTile > 0 && keep > opts.MaxPerTile {
+		keep = opts.MaxPerTile
+	}
+	node.instances = instances[:keep]
+	node.Count = keep
+	node.Types = make(map[string]int)
+	for i := range node.instances {
+		node.Types[node.instances[i].PositionType]++
+	}
+
+	var buckets [8][]Instance
+	c := node.Bounds.center()
+	for _, inst := range instances[keep:] {
+		o := 0
+		p := [3]float64{inst.Position.X, inst.Position.Y, inst.Position.Z}
+		for axis := 0; axis < 3; axis++ {
+			if p[axis] >= c[axis] {
+				o |= 1 << axis
+			}
+		}
+		buckets[o] = append(buckets[o], inst)
+	}
+	for o, bucket := range buckets {
+		if len(bucket) == 0 {
+			continue
+		}
+		child := &TileNode{
+			ID:     node.ID + strconv.Itoa(o),
+			Level:  node.Level + 1,
+			Bounds: node.Bounds.octant(o),
+		}
+		fillTile(child, bucket, opts)
+		node.Children = append(node.Children, child)
+	}
+}
+
+// cubeBounds returns the smallest cube around every position, so octants
+// stay cubic at every level
+func cubeBounds(instances []Instance) TileBounds {
+	if len(instances) == 0 {
+		return TileBounds{}
+	}
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i := range instances {
+		p := [3]float64{instances[i].Position.X, instances[i].Position.Y, instances[i].Position.Z}
+		for axis := 0; axis < 3; axis++ {
+			lo[axis] = math.Min(lo[axis], p[axis])
+			hi[axis] = math.Max(hi[axis], p[axis])
+		}
+	}
+	size := math.Max(hi[0]-lo[0], math.Max(hi[1]-lo[1], hi[2]-lo[2]))
+	var b TileBounds
+	for axis := 0; axis < 3; axis++ {
+		mid := (lo[axis] + hi[axis]) / 2
+		b.Min[axis] = mid - size/2
+		b.Max[axis] = mid + size/2
+	}
+	return b
+}
+
+// ============================================================================
+// Writing
+// ============================================================================
+
+// tileExtension returns the file extension for tiles in format/compression
+func tileExtension(format, compression string) string {
+	ext := ".json"
+	switch format {
+	case FormatNDJSON:
+		ext = ".ndjson"
+	case FormatBinary:
+		ext = ".bin"
+	}
+	switch compression {
+	case CompressionGzip:
+		ext += ".gz"
+	case CompressionZstd:
+		ext += ".zst"
+	}
+	return ext
+}
+
+// WriteTileset writes every tile of root into dir in the given format and
+// compression, followed by the tileset.json manifest. Binary tiles are
+// always written uncompressed.
+func WriteTileset(dir string, root *TileNode, opts TileOptions, format, compression string, binaryScale float64) error {
+	if format == "" {
+		format = FormatJSON
+	}
+	if compression == "" {
+		compression = CompressionNone
+	}
+	if format == FormatBinary && compression != CompressionNone {
+		return fmt.Errorf("binary tiles cannot be %s compressed; the loader reads them as raw typed arrays", compression)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create tile directory %q: %w", dir, err)
+	}
+
+	ext := tileExtension(format, compression)
+	var write func(node *TileNode) error
+	write = func(node *TileNode) error {
+		node.Content = node.ID + ext
+		path := filepath.Join(dir, node.Content)
+		var err error
+		if format == FormatBinary {
+			err = WriteBinaryInstances(path, node.instances, binaryScale)
+		} else {
+			err = WriteInstances(path, format, compression, node.instances)
+		}
+		if err != nil {
+			return fmt.Errorf("tile %s: %w", node.ID, err)
+		}
+		for _, child := range node.Children {
+			if err := write(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := write(root); err != nil {
+		return err
+	}
+
+	manifest := Tileset{
+		Version:    TilesetVersion,
+		Format:     format,
+		Total:      root.Total,
+		MaxPerTile: opts.MaxPerTile,
+		Root:       root,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal tileset: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tileset.json"), data, 0644); err != nil {
+		return fmt.Errorf("cannot write tileset: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Octree Partitioning Tests
+// ============================================================
+
+func tileFixture() []Instance {
+	types := []string{"dust", "satellite", "asteroid", "planet"}
+	var instances []Instance
+	for i := 0; i < 200; i++ {
+		instances = append(instances, Instance{
+			Domain:       fmt.Sprintf("i%03d.example", i),
+			Stats:        &Stats{UserCount: i + 1},
+			Position:     &Position{X: float64(i%10) * 100, Y: float64(i/10%10) * 100, Z: float64(i%7) * 50},
+			PositionType: types[i%4],
+		})
+	}
+	instances = append(instances,
+		Instance{Domain: "big.example", Position: &Position{X: 450, Y: 450}, PositionType: "supergiant"},
+		Instance{Domain: "unplaced.example"},
+	)
+	return instances
+}
+
+// walkTiles visits every node depth-first
+func walkTiles(node *TileNode, visit func(*TileNode)) {
+	visit(node)
+	for _, child := range node.Children {
+		walkTiles(child, visit)
+	}
+}
+
+func TestBuildOctree_CountsAndBounds(t *testing.T) {
+	root := BuildOctree(tileFixture(), TileOptions{MaxPerTile: 20, MaxDepth: 4})
+
+	if root.Total != 201 {
+		t.Errorf("Root total = %d, want 201 positioned instances", root.Total)
+	}
+	seen := 0
+	walkTiles(root, func(n *TileNode) {
+		seen += n.Count
+		if n.Count > 20 && n.Level < 4 {
+			t.Errorf("Tile %s holds %d instances above the limit", n.ID, n.Count)
+		}
+		sum := n.Count
+		for _, c := range n.Children {
+			sum += c.Total
+		}
+		if sum != n.Total {
+			t.Errorf("Tile %s total %d != count plus children %d", n.ID, n.Total, sum)
+		}
+		for _, inst := range n.instances {
+			p := [3]float64{inst.Position.X, inst.Position.Y, inst.Position.Z}
+			for axis := 0; axis < 3; axis++ {
+				if p[axis] < n.Bounds.Min[axis] || p[axis] > n.Bounds.Max[axis] {
+					t.Errorf("%s lies outside tile %s", inst.Domain, n.ID)
+				}
+			}
+		}
+	})
+	if seen != 201 {
+		t.Errorf("Tiles hold %d instances, want each of 201 exactly once", seen)
+	}
+}
+
+func TestBuildOctree_LevelOfDetail(t *testing.T) {
+	root := BuildOctree(tileFixture(), TileOptions{MaxPerTile: 20, MaxDepth: 4})
+
+	// The root carries the supergiant and then the largest planets
+	if root.instances[0].Domain != "big.example" {
+		t.Errorf("Expected the supergiant first in the root tile, got %s", root.instances[0].Domain)
+	}
+	if root.Types["dust"] != 0 || root.Types["planet"] != 19 {
+		t.Errorf("Root tile should hold only the supergiant and planets, got %v", root.Types)
+	}
+
+	// Dust only appears below the root
+	deepestDust := 0
+	walkTiles(root, func(n *TileNode) {
+		if n.Types["dust"] > 0 && n.Level > deepestDust {
+			deepestDust = n.Level
+		}
+	})
+	if deepestDust < 2 {
+		t.Errorf("Expected dust in deep tiles, deepest level with dust is %d", deepestDust)
+	}
+}
+
+func TestBuildOctree_MaxDepth(t *testing.T) {
+	// Identical positions can never be separated; the depth limit stops recursion
+	var same []Instance
+	for i := 0; i < 50; i++ {
+		same = append(same, Instance{Domain: fmt.Sprintf("s%d", i), Position: &Position{}, PositionType: "dust"})
+	}
+	root := BuildOctree(same, TileOptions{MaxPerTile: 5, MaxDepth: 3})
+	deepest := 0
+	walkTiles(root, func(n *TileNode) {
+		if n.Level > deepest {
+			deepest = n.Level
+		}
+	})
+	if deepest != 3 {
+		t.Errorf("Expected recursion to stop at depth 3, got %d", deepest)
+	}
+}
+
+// ============================================================
+// B. Tileset Output Tests
+// ============================================================
+
+func TestWriteTileset(t *testing.T) {
+	dir := t.TempDir()
+	opts := TileOptions{MaxPerTile: 50, MaxDepth: 3}
+	root := BuildOctree(tileFixture(), opts)
+	if err := WriteTileset(dir, root, opts, FormatNDJSON, CompressionGzip, 1); err != nil {
+		t.Fatalf("WriteTileset failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "tileset.json"))
+	if err != nil {
+		t.Fatalf("Manifest missing: %v", err)
+	}
+	var manifest Tileset
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Manifest does not parse: %v", err)
+	}
+	if manifest.Version != TilesetVersion || manifest.Total != 201 || manifest.Root.Content != "r.ndjson.gz" {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	walkTiles(manifest.Root, func(n *TileNode) {
+		got, err := ReadInstances(filepath.Join(dir, n.Content), "")
+		if err != nil || len(got) != n.Count {
+			t.Errorf("Tile %s: read %d instances (%v), manifest says %d", n.ID, len(got), err, n.Count)
+		}
+	})
+}
+
+func TestWriteTileset_RejectsCompressedBinary(t *testing.T) {
+	dir := t.TempDir()
+	opts := TileOptions{MaxPerTile: 50, MaxDepth: 3}
+	root := BuildOctree(tileFixture(), opts)
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		err := WriteTileset(dir, root, opts, FormatBinary, compression, 1)
+		if err == nil || !strings.Contains(err.Error(), "binary tiles") {
+			t.Errorf("%s: expected binary tiles to be rejected, got %v", compression, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Rejected tileset left %d files behind", len(entries))
+	}
+}
@@ -1,20 +1,44 @@
 package main
 
 type Instance struct {
-	Domain       string        `json:"domain"`
-	Name         string        `json:"name,omitempty"`
-	Description  string        `json:"description,omitempty"`
-	Software     *Software     `json:"software,omitempty"`
-	Stats        *Stats        `json:"stats,omitempty"`
-	FirstSeenAt  string        `json:"first_seen_at,omitempty"`
-	CreationTime *CreationTime `json:"creation_time,omitempty"`
-	Color        *Color        `json:"color,omitempty"`
-	Position     *Position     `json:"position,omitempty"`
-	PositionType string        `json:"positionType,omitempty"`
+	Domain            string        `json:"domain"`
+	Name              string        `json:"name,omitempty"`
+	Description       string        `json:"description,omitempty"`
+	Software          *Software     `json:"software,omitempty"`
+	Stats             *Stats        `json:"stats,omitempty"`
+	OpenRegistrations *bool         `json:"open_registrations,omitempty"`
+	FirstSeenAt       string        `json:"first_seen_at,omitempty"`
+	CreationTime      *CreationTime `json:"creation_time,omitempty"`
+	Color             *Color        `json:"color,omitempty"`
+	Position          *Position     `json:"position,omitempty"`
+	PositionType      string        `json:"positionType,omitempty"`
+	Nebula            string        `json:"nebula,omitempty"` // Cluster an unknown-software instance was placed in
+
+	// Provenance maps field names (e.g. "stats.user_count") to the source that supplied them
+	Provenance map[string]string `json:"provenance,omitempty"`
+
+	// Peers lists the domains this instance federates with (see LoadPeerGraph).
+	// It feeds the force layout and is never written to the output.
+	Peers []string `json:"-"`
+
+	// NebulaGroup is the instance's group from dust.nebula_map (see
+	// AttachNebulaMap). It is never written to the output.
+	NebulaGroup string `json:"-"`
+
+	// Previous is the instance's placement in the -previous output (see
+	// AttachPrevious), and Anchored is set when the layout kept it. Neither
+	// is written to the output.
+	Previous *Placement `json:"-"`
+	Anchored bool       `json:"-"`
+
+	// DustStrategy records how an unknown-software instance was placed, for
+	// the statistics report. It is never written to the output.
+	DustStrategy string `json:"-"`
 }
 
 type Software struct {
-	Name string `json:"name"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
 }
 
 type Stats struct {
@@ -64,59 +88,165 @@ type Position struct {
 	Z float64 `json:"z"`
 }
 
+// Supergiant is one of the instances anchoring the galactic core
+type Supergiant struct {
+	Domain   string    `json:"domain" yaml:"domain"`
+	Software string    `json:"software" yaml:"software"`                     // Software whose spiral arm starts here ("" = none)
+	Position *Position `json:"position,omitempty" yaml:"position,omitempty"` // Explicit coordinates (default: on the core polygon)
+}
+
 type Config struct {
-	GenesisDate string
-	EraPre2019  string
-	EraPost2024 string
-
-	HueYoung          float64
-	HueOld            float64
-	DomainHashRange   float64
-	EraPre2019Offset  float64
-	EraPost2024Offset float64
-	SaturationMin     float64
-	SaturationMax     float64
-	LightnessMin      float64
-	LightnessMax      float64
-
-	MaxUserCount int
+	// Reference "as-of" time for all age math (empty = now). Pin it to make
+	// colors reproducible for an archived snapshot.
+	AsOf string `json:"as_of" yaml:"as_of"`
 
-	// Galactic Core Configuration
-	SupergiantDomains []string
-	SupergiantRadius  float64
-	SupergiantZHeight float64
+	// How to date instances whose creation date is missing or unparseable:
+	// "skip", "genesis", "median" or "now"
+	TimestampFallback string `json:"timestamp_fallback" yaml:"timestamp_fallback"`
+
+	// How to collapse records whose domains normalize to the same host:
+	// "first", "last", "most_users" or "merge"
+	DuplicatePolicy string `json:"duplicate_policy" yaml:"duplicate_policy"`
+
+	// Registered layout that places instances (see `layouts list`)
+	Layout string `json:"layout" yaml:"layout"`
+
+	// Goroutines used for colors and positions (1 = serial). The output is
+	// identical for every value.
+	Workers int `json:"workers" yaml:"workers"`
+
+	// Earlier output (e.g. fediverse_final.json) whose positions are kept for
+	// instances that stay in the same system and size class. Empty places
+	// every instance afresh.
+	Previous string `json:"previous" yaml:"previous"`
+
+	GenesisDate string `json:"genesis_date" yaml:"genesis_date"`
+	EraPre2019  string `json:"era_pre_2019" yaml:"era_pre_2019"`
+	EraPost2024 string `json:"era_post_2024" yaml:"era_post_2024"`
+
+	HueYoung          float64 `json:"hue_young" yaml:"hue_young"`
+	HueOld            float64 `json:"hue_old" yaml:"hue_old"`
+	DomainHashRange   float64 `json:"domain_hash_range" yaml:"domain_hash_range"`
+	EraPre2019Offset  float64 `json:"era_pre_2019_offset" yaml:"era_pre_2019_offset"`
+	EraPost2024Offset float64 `json:"era_post_2024_offset" yaml:"era_post_2024_offset"`
+	SaturationMin     float64 `json:"saturation_min" yaml:"saturation_min"`
+	SaturationMax     float64 `json:"saturation_max" yaml:"saturation_max"`
+	LightnessMin      float64 `json:"lightness_min" yaml:"lightness_min"`
+	LightnessMax      float64 `json:"lightness_max" yaml:"lightness_max"`
+
+	MaxUserCount int `json:"max_user_count" yaml:"max_user_count"`
+
+	// Galactic Core Configuration. Supergiants without an explicit position
+	// sit on a regular polygon of radius supergiant_radius.
+	Supergiants       []Supergiant `json:"supergiants" yaml:"supergiants"`
+	SupergiantRadius  float64      `json:"supergiant_radius" yaml:"supergiant_radius"`
+	SupergiantZHeight float64      `json:"supergiant_z_height" yaml:"supergiant_z_height"`
 
 	// Planetary System Tiers
-	TierAInstanceCount int
-	TierBInstanceCount int
+	TierAInstanceCount int `json:"tier_a_instance_count" yaml:"tier_a_instance_count"`
+	TierBInstanceCount int `json:"tier_b_instance_count" yaml:"tier_b_instance_count"`
 
 	// System Center Radii (distance from galactic core)
-	TierASystemRadius    float64
-	TierASystemRadiusVar float64
-	TierBSystemRadius    float64
-	TierBSystemRadiusVar float64
-	TierCSystemRadius    float64
-	TierCSystemRadiusVar float64
+	TierASystemRadius    float64 `json:"tier_a_system_radius" yaml:"tier_a_system_radius"`
+	TierASystemRadiusVar float64 `json:"tier_a_system_radius_var" yaml:"tier_a_system_radius_var"`
+	TierBSystemRadius    float64 `json:"tier_b_system_radius" yaml:"tier_b_system_radius"`
+	TierBSystemRadiusVar float64 `json:"tier_b_system_radius_var" yaml:"tier_b_system_radius_var"`
+	TierCSystemRadius    float64 `json:"tier_c_system_radius" yaml:"tier_c_system_radius"`
+	TierCSystemRadiusVar float64 `json:"tier_c_system_radius_var" yaml:"tier_c_system_radius_var"`
 
 	// Within-System Radii
-	TierASystemMaxRadius    float64
-	TierBSystemMaxRadius    float64
-	TierCSystemMaxRadius    float64
-	SystemRadiusScaleFactor float64
+	TierASystemMaxRadius    float64 `json:"tier_a_system_max_radius" yaml:"tier_a_system_max_radius"`
+	TierBSystemMaxRadius    float64 `json:"tier_b_system_max_radius" yaml:"tier_b_system_max_radius"`
+	TierCSystemMaxRadius    float64 `json:"tier_c_system_max_radius" yaml:"tier_c_system_max_radius"`
+	SystemRadiusScaleFactor float64 `json:"system_radius_scale_factor" yaml:"system_radius_scale_factor"`
 
 	// Z-Axis Variation
-	ZAxisVariationFactor float64
+	ZAxisVariationFactor float64 `json:"z_axis_variation_factor" yaml:"z_axis_variation_factor"`
 
 	// Instance Size Thresholds
-	PlanetUserThreshold    int
-	AsteroidUserThreshold  int
-	SatelliteUserThreshold int
+	PlanetUserThreshold    int `json:"planet_user_threshold" yaml:"planet_user_threshold"`
+	AsteroidUserThreshold  int `json:"asteroid_user_threshold" yaml:"asteroid_user_threshold"`
+	SatelliteUserThreshold int `json:"satellite_user_threshold" yaml:"satellite_user_threshold"`
 
 	// Staggering and Distribution
-	RadialVariationFactor float64
+	RadialVariationFactor float64 `json:"radial_variation_factor" yaml:"radial_variation_factor"`
+
+	// Galaxy morphology (layouts spiral, barred, elliptical and ring)
+	Galaxy GalaxyConfig `json:"galaxy" yaml:"galaxy"`
+
+	// Unknown-software dust (layouts spiral and barred)
+	Dust DustConfig `json:"dust" yaml:"dust"`
+
+	// Force-directed layout (layout: force)
+	Force ForceConfig `json:"force" yaml:"force"`
+
+	// Minimum spacing enforced after any layout (opt-in)
+	Separation SeparationConfig `json:"separation" yaml:"separation"`
+}
+
+// SeparationConfig controls the collision pass that spreads out overlapping instances
+type SeparationConfig struct {
+	MinDistance float64            `json:"min_distance" yaml:"min_distance"` // Spacing between two scale-1 instances (0 disables the pass)
+	Iterations  int                `json:"iterations" yaml:"iterations"`     // Maximum relaxation passes
+	TypeScale   map[string]float64 `json:"type_scale" yaml:"type_scale"`     // Spacing multiplier per position type (default 1)
+}
+
+// GalaxyConfig shapes the galaxy layouts. Arms are logarithmic spirals
+// r = start_radius * e^(pitch * theta) swept through turns full rotations.
+// Dust arms are derived from the main arms so the two stay aligned.
+type GalaxyConfig struct {
+	Arms                  int     `json:"arms" yaml:"arms"`                                       // Main arms; Tier A systems take them round-robin
+	Pitch                 float64 `json:"pitch" yaml:"pitch"`                                     // Growth rate per radian (tan of the pitch angle)
+	Turns                 float64 `json:"turns" yaml:"turns"`                                     // Rotations along an arm (arm length)
+	StartRadius           float64 `json:"start_radius" yaml:"start_radius"`                       // Where arms without a supergiant begin
+	SupergiantStartRadius float64 `json:"supergiant_start_radius" yaml:"supergiant_start_radius"` // Where arms begin, measured from their supergiant
+	WaveAmplitude         float64 `json:"wave_amplitude" yaml:"wave_amplitude"`                   // Vertical wave height as a fraction of the radius
+	DustRadiusScale       float64 `json:"dust_radius_scale" yaml:"dust_radius_scale"`             // Dust arm start radius relative to start_radius
+	DustPitchScale        float64 `json:"dust_pitch_scale" yaml:"dust_pitch_scale"`               // Dust arm pitch relative to pitch
+	DustWaveScale         float64 `json:"dust_wave_scale" yaml:"dust_wave_scale"`                 // Dust wave amplitude relative to wave_amplitude
+
+	// Other morphologies (layouts barred, elliptical and ring)
+	BarLength        float64 `json:"bar_length" yaml:"bar_length"`                 // Half-length of the central bar; arms start at its ends
+	BarWidth         float64 `json:"bar_width" yaml:"bar_width"`                   // Half-width of the central bar
+	EllipticalSigmaX float64 `json:"elliptical_sigma_x" yaml:"elliptical_sigma_x"` // Standard deviation along each axis of an elliptical galaxy
+	EllipticalSigmaY float64 `json:"elliptical_sigma_y" yaml:"elliptical_sigma_y"`
+	EllipticalSigmaZ float64 `json:"elliptical_sigma_z" yaml:"elliptical_sigma_z"`
+	RingRadius       float64 `json:"ring_radius" yaml:"ring_radius"` // Radius of a ring galaxy's ring
+	RingWidth        float64 `json:"ring_width" yaml:"ring_width"`   // Spread of systems and dust across the ring
+}
+
+// DustConfig chooses where instances of unknown software go. Each instance
+// picks a strategy with probability proportional to its weight.
+type DustConfig struct {
+	Weights         map[string]float64 `json:"weights" yaml:"weights"`                   // Relative weight per strategy: inner, arm, nebula, halo (absent = unused)
+	InnerMinRadius  float64            `json:"inner_min_radius" yaml:"inner_min_radius"` // Inner dust shell between the planetary systems
+	InnerMaxRadius  float64            `json:"inner_max_radius" yaml:"inner_max_radius"`
+	NebulaMinRadius float64            `json:"nebula_min_radius" yaml:"nebula_min_radius"` // Distance of nebula centers from the core
+	NebulaMaxRadius float64            `json:"nebula_max_radius" yaml:"nebula_max_radius"`
+	NebulaSize      float64            `json:"nebula_size" yaml:"nebula_size"`         // Radius of a single nebula
+	NebulaKey       string             `json:"nebula_key" yaml:"nebula_key"`           // Which instances share a nebula: registrable_domain, tld, provider, mapping or prefix
+	NebulaMap       string             `json:"nebula_map" yaml:"nebula_map"`           // JSON file mapping domains to groups (nebula_key: mapping)
+	HaloMinRadius   float64            `json:"halo_min_radius" yaml:"halo_min_radius"` // Diffuse outer halo shell
+	HaloMaxRadius   float64            `json:"halo_max_radius" yaml:"halo_max_radius"`
+}
+
+// ForceConfig tunes the force-directed peer-graph layout
+type ForceConfig struct {
+	Peers      string  `json:"peers" yaml:"peers"`           // Peer list file or directory (see LoadPeerGraph)
+	Iterations int     `json:"iterations" yaml:"iterations"` // Simulation steps
+	Seed       int64   `json:"seed" yaml:"seed"`             // Seed for the initial positions
+	Theta      float64 `json:"theta" yaml:"theta"`           // Barnes-Hut opening angle (0 = exact, larger = faster)
+	MaxPeers   int     `json:"max_peers" yaml:"max_peers"`   // Federation edges kept per instance, largest peers first
+	Gravity    float64 `json:"gravity" yaml:"gravity"`       // Pull toward the core that keeps disconnected instances near
+	Radius     float64 `json:"radius" yaml:"radius"`         // Radius the finished layout is scaled to
 }
 
 var DefaultConfig = Config{
+	TimestampFallback: TimestampFallbackNow,
+	DuplicatePolicy:   DuplicateMerge,
+	Layout:            DefaultLayout,
+	Workers:           1,
+
 	GenesisDate: "2016-11-23T00:00:00Z",
 	EraPre2019:  "2019-01-01T00:00:00Z",
 	EraPost2024: "2024-01-01T00:00:00Z",
@@ -134,7 +264,11 @@ var DefaultConfig = Config{
 	MaxUserCount: 3000000,
 
 	// Galactic Core Configuration
-	SupergiantDomains: []string{"mastodon.social", "misskey.io", "pixelfed.social"},
+	Supergiants: []Supergiant{
+		{Domain: "mastodon.social", Software: "Mastodon"},
+		{Domain: "misskey.io", Software: "Misskey"},
+		{Domain: "pixelfed.social", Software: "Pixelfed"},
+	},
 	SupergiantRadius:  3000,
 	SupergiantZHeight: 1500,
 
@@ -166,4 +300,67 @@ var DefaultConfig = Config{
 
 	// Staggering and Distribution
 	RadialVariationFactor: 0.15,
+
+	// Spiral galaxy morphology
+	Galaxy: GalaxyConfig{
+		Arms:                  5,
+		Pitch:                 0.25,
+		Turns:                 1.25,
+		StartRadius:           5000,
+		SupergiantStartRadius: 2000,
+		WaveAmplitude:         0.15,
+		DustRadiusScale:       1.6,
+		DustPitchScale:        1.2,
+		DustWaveScale:         0.8,
+
+		BarLength:        5000,
+		BarWidth:         800,
+		EllipticalSigmaX: 9000,
+		EllipticalSigmaY: 6000,
+		EllipticalSigmaZ: 4000,
+		RingRadius:       14000,
+		RingWidth:        2000,
+	},
+
+	// Unknown-software dust
+	Dust: DustConfig{
+		Weights: map[string]float64{
+			"inner":  0.65,
+			"arm":    0.20,
+			"nebula": 0.10,
+			"halo":   0.05,
+		},
+		InnerMinRadius:  2000,
+		InnerMaxRadius:  10000,
+		NebulaMinRadius: 10000,
+		NebulaMaxRadius: 22000,
+		NebulaSize:      800,
+		NebulaKey:       NebulaKeyRegistrableDomain,
+		HaloMinRadius:   25000,
+		HaloMaxRadius:   40000,
+	},
+
+	// Force-directed layout
+	Force: ForceConfig{
+		Iterations: 100,
+		Seed:       1,
+		Theta:      0.8,
+		MaxPeers:   50,
+		Gravity:    0.05,
+		Radius:     20000,
+	},
+
+	// Collision resolution (off unless min_distance is set, e.g. to 25)
+	Separation: SeparationConfig{
+		MinDistance: 0,
+		Iterations:  10,
+		TypeScale: map[string]float64{
+			"supergiant": 4,
+			"planet":     2,
+			"asteroid":   1.5,
+			"satellite":  1,
+			"dust":       0.75,
+			"unknown":    0.75,
+		},
+	},
 }
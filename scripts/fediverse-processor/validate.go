@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every invariant a Config violates
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s",
+		len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// configValidator accumulates problems so all of them are reported at once
+type configValidator struct {
+	problems []string
+}
+
+func (v *configValidator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *configValidator) date(field, value string) (time.Time, bool) {
+	t, err := parseTimeStrict(value)
+	if err != nil {
+		v.addf("%s: %v", field, err)
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (v *configValidator) rangeOrder(minField string, min float64, maxField string, max float64) {
+	if min > max {
+		v.addf("%s (%g) must not exceed %s (%g)", minField, min, maxField, max)
+	}
+}
+
+func (v *configValidator) within(field string, value, lo, hi float64) {
+	if value < lo || value > hi {
+		v.addf("%s (%g) must be within [%g, %g]", field, value, lo, hi)
+	}
+}
+
+func (v *configValidator) positive(field string, value float64) {
+	if value <= 0 {
+		v.addf("%s (%g) must be positive", field, value)
+	}
+}
+
+func (v *configValidator) nonNegative(field string, value float64) {
+	if value < 0 {
+		v.addf("%s (%g) must not be negative", field, value)
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the semantic invariants of a Config. It returns a
+// *ValidationError listing every violated invariant, or nil if cfg is usable.
+func (cfg Config) Validate() error {
+	v := &configValidator{}
+
+	// Era dates must parse and be in chronological order
+	genesis, okGenesis := v.date("genesis_date", cfg.GenesisDate)
+	if cfg.AsOf != "" {
+		if asOf, ok := v.date("as_of", cfg.AsOf); ok && okGenesis && asOf.Before(genesis) {
+			v.addf("as_of (%s) must not be before genesis_date (%s)", cfg.AsOf, cfg.GenesisDate)
+		}
+	}
+	if !containsString(timestampFallbacks, cfg.TimestampFallback) {
+		v.addf("timestamp_fallback (%q) must be one of %s", cfg.TimestampFallback, strings.Join(timestampFallbacks, ", "))
+	}
+	if !containsString(duplicatePolicies, cfg.DuplicatePolicy) {
+		v.addf("duplicate_policy (%q) must be one of %s", cfg.DuplicatePolicy, strings.Join(duplicatePolicies, ", "))
+	}
+	if _, ok := layouts[cfg.Layout]; !ok {
+		v.addf("layout (%q) must be one of %s", cfg.Layout, strings.Join(layoutNames(), ", "))
+	}
+	v.positive("workers", float64(cfg.Workers))
+	if cfg.Previous != "" && cfg.Layout == "force" {
+		v.addf("previous is not supported by the force layout, which re-simulates every run")
+	}
+	pre2019, okPre := v.date("era_pre_2019", cfg.EraPre2019)
+	post2024, okPost := v.date("era_post_2024", cfg.EraPost2024)
+	if okGenesis && okPre && pre2019.Before(genesis) {
+		v.addf("era_pre_2019 (%s) must not be before genesis_date (%s)", cfg.EraPre2019, cfg.GenesisDate)
+	}
+	if okPre && okPost && post2024.Before(pre2019) {
+		v.addf("era_post_2024 (%s) must not be before era_pre_2019 (%s)", cfg.EraPost2024, cfg.EraPre2019)
+	}
+
+	// Color mapping
+	v.within("hue_young", cfg.HueYoung, 0, 360)
+	v.within("hue_old", cfg.HueOld, 0, 360)
+	v.nonNegative("domain_hash_range", cfg.DomainHashRange)
+	v.within("saturation_min", cfg.SaturationMin, 0, 100)
+	v.within("saturation_max", cfg.SaturationMax, 0, 100)
+	v.rangeOrder("saturation_min", cfg.SaturationMin, "saturation_max", cfg.SaturationMax)
+	v.within("lightness_min", cfg.LightnessMin, 0, 100)
+	v.within("lightness_max", cfg.LightnessMax, 0, 100)
+	v.rangeOrder("lightness_min", cfg.LightnessMin, "lightness_max", cfg.LightnessMax)
+	v.positive("max_user_count", float64(cfg.MaxUserCount))
+
+	// Galactic core
+	seenDomain := make(map[string]bool)
+	seenSoftware := make(map[string]bool)
+	for i, sg := range cfg.Supergiants {
+		if sg.Domain == "" {
+			v.addf("supergiants[%d].domain must not be empty", i)
+		} else if seenDomain[sg.Domain] {
+			v.addf("supergiants[%d].domain (%q) is listed more than once", i, sg.Domain)
+		}
+		seenDomain[sg.Domain] = true
+		if sg.Software != "" && seenSoftware[sg.Software] {
+			v.addf("supergiants[%d].software (%q) already starts another supergiant's arm", i, sg.Software)
+		}
+		seenSoftware[sg.Software] = true
+	}
+	v.nonNegative("supergiant_radius", cfg.SupergiantRadius)
+
+	// Tiers: a larger system must never need fewer instances than a smaller one
+	v.positive("tier_b_instance_count", float64(cfg.TierBInstanceCount))
+	if cfg.TierBInstanceCount > cfg.TierAInstanceCount {
+		v.addf("tier_b_instance_count (%d) must not exceed tier_a_instance_count (%d)",
+			cfg.TierBInstanceCount, cfg.TierAInstanceCount)
+	}
+
+	// Radii
+	v.positive("tier_a_system_radius", cfg.TierASystemRadius)
+	v.positive("tier_b_system_radius", cfg.TierBSystemRadius)
+	v.positive("tier_c_system_radius", cfg.TierCSystemRadius)
+	v.nonNegative("tier_a_system_radius_var", cfg.TierASystemRadiusVar)
+	v.nonNegative("tier_b_system_radius_var", cfg.TierBSystemRadiusVar)
+	v.nonNegative("tier_c_system_radius_var", cfg.TierCSystemRadiusVar)
+	v.positive("tier_a_system_max_radius", cfg.TierASystemMaxRadius)
+	v.positive("tier_b_system_max_radius", cfg.TierBSystemMaxRadius)
+	v.positive("tier_c_system_max_radius", cfg.TierCSystemMaxRadius)
+	v.nonNegative("system_radius_scale_factor", cfg.SystemRadiusScaleFactor)
+	v.nonNegative("z_axis_variation_factor", cfg.ZAxisVariationFactor)
+	v.within("radial_variation_factor", cfg.RadialVariationFactor, 0, 1)
+
+	// Size thresholds must be ordered planet >= asteroid >= satellite >= 0
+	v.nonNegative("satellite_user_threshold", float64(cfg.SatelliteUserThreshold))
+	if cfg.AsteroidUserThreshold < cfg.SatelliteUserThreshold {
+		v.addf("asteroid_user_threshold (%d) must not be below satellite_user_threshold (%d)",
+			cfg.AsteroidUserThreshold, cfg.SatelliteUserThreshold)
+	}
+	if cfg.PlanetUserThreshold < cfg.AsteroidUserThreshold {
+		v.addf("planet_user_threshold (%d) must not be below asteroid_user_threshold (%d)",
+			cfg.PlanetUserThreshold, cfg.AsteroidUserThreshold)
+	}
+
+	// Galaxy morphology
+	v.positive("galaxy.arms", float64(cfg.Galaxy.Arms))
+	v.nonNegative("galaxy.pitch", cfg.Galaxy.Pitch)
+	v.positive("galaxy.turns", cfg.Galaxy.Turns)
+	v.positive("galaxy.start_radius", cfg.Galaxy.StartRadius)
+	v.positive("galaxy.supergiant_start_radius", cfg.Galaxy.SupergiantStartRadius)
+	v.nonNegative("galaxy.wave_amplitude", cfg.Galaxy.WaveAmplitude)
+	v.positive("galaxy.dust_radius_scale", cfg.Galaxy.DustRadiusScale)
+	v.positive("galaxy.dust_pitch_scale", cfg.Galaxy.DustPitchScale)
+	v.nonNegative("galaxy.dust_wave_scale", cfg.Galaxy.DustWaveScale)
+	v.positive("galaxy.bar_length", cfg.Galaxy.BarLength)
+	v.nonNegative("galaxy.bar_width", cfg.Galaxy.BarWidth)
+	v.positive("galaxy.elliptical_sigma_x", cfg.Galaxy.EllipticalSigmaX)
+	v.positive("galaxy.elliptical_sigma_y", cfg.Galaxy.EllipticalSigmaY)
+	v.positive("galaxy.elliptical_sigma_z", cfg.Galaxy.EllipticalSigmaZ)
+	v.positive("galaxy.ring_radius", cfg.Galaxy.RingRadius)
+	v.nonNegative("galaxy.ring_width", cfg.Galaxy.RingWidth)
+
+	// Unknown-software dust
+	totalWeight := 0.0
+	for _, s := range sortedKeys(cfg.Dust.Weights) {
+		if !containsString(dustStrategies, s) {
+			v.addf("dust.weights: unknown strategy %q (want one of %s)", s, strings.Join(dustStrategies, ", "))
+		}
+		v.nonNegative("dust.weights."+s, cfg.Dust.Weights[s])
+		totalWeight += cfg.Dust.Weights[s]
+	}
+	if totalWeight <= 0 {
+		v.addf("dust.weights must give at least one strategy a positive weight")
+	}
+	v.nonNegative("dust.inner_min_radius", cfg.Dust.InnerMinRadius)
+	v.rangeOrder("dust.inner_min_radius", cfg.Dust.InnerMinRadius, "dust.inner_max_radius", cfg.Dust.InnerMaxRadius)
+	v.nonNegative("dust.nebula_min_radius", cfg.Dust.NebulaMinRadius)
+	v.rangeOrder("dust.nebula_min_radius", cfg.Dust.NebulaMinRadius, "dust.nebula_max_radius", cfg.Dust.NebulaMaxRadius)
+	v.nonNegative("dust.nebula_size", cfg.Dust.NebulaSize)
+	if !containsString(nebulaKeyPolicies, cfg.Dust.NebulaKey) {
+		v.addf("dust.nebula_key (%q) must be one of %s", cfg.Dust.NebulaKey, strings.Join(nebulaKeyPolicies, ", "))
+	}
+	if cfg.Dust.NebulaKey == NebulaKeyMapping && cfg.Dust.NebulaMap == "" {
+		v.addf("dust.nebula_map must name a mapping file when dust.nebula_key is mapping")
+	}
+	v.nonNegative("dust.halo_min_radius", cfg.Dust.HaloMinRadius)
+	v.rangeOrder("dust.halo_min_radius", cfg.Dust.HaloMinRadius, "dust.halo_max_radius", cfg.Dust.HaloMaxRadius)
+
+	// Force-directed layout
+	if cfg.Layout == "force" && cfg.Force.Peers == "" {
+		v.addf("force.peers must name a peer list file or directory when layout is force")
+	}
+	v.positive("force.iterations", float64(cfg.Force.Iterations))
+	v.within("force.theta", cfg.Force.Theta, 0, 2)
+	v.positive("force.max_peers", float64(cfg.Force.MaxPeers))
+	v.nonNegative("force.gravity", cfg.Force.Gravity)
+	v.positive("force.radius", cfg.Force.Radius)
+
+	// Collision resolution
+	v.nonNegative("separation.min_distance", cfg.Separation.MinDistance)
+	v.nonNegative("separation.iterations", float64(cfg.Separation.Iterations))
+	for _, t := range sortedKeys(cfg.Separation.TypeScale) {
+		if !containsString(positionTypes, t) {
+			v.addf("separation.type_scale: unknown position type %q (want one of %s)", t, strings.Join(positionTypes, ", "))
+		}
+		v.positive("separation.type_scale."+t, cfg.Separation.TypeScale[t])
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
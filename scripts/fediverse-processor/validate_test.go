@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// A. Config Validation Tests
+// ============================================================
+
+func TestValidate_DefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig.Validate(); err != nil {
+		t.Errorf("DefaultConfig should be valid, got: %v", err)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.SaturationMin = 95
+	cfg.SaturationMax = 40
+	cfg.TierBInstanceCount = 500
+	cfg.AsteroidUserThreshold = 5000
+	cfg.GenesisDate = "not-a-date"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+
+	wantFields := []string{
+		"genesis_date",
+		"saturation_min (95) must not exceed saturation_max (40)",
+		"tier_b_instance_count",
+		"planet_user_threshold (1000) must not be below asteroid_user_threshold (5000)",
+	}
+	for _, want := range wantFields {
+		found := false
+		for _, p := range verr.Problems {
+			if strings.Contains(p, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected a problem mentioning %q, got %v", want, verr.Problems)
+		}
+	}
+}
+
+func TestValidate_EraOrder(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.EraPre2019 = "2025-01-01T00:00:00Z"
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "era_post_2024") {
+		t.Errorf("Expected era ordering problem, got %v", err)
+	}
+}
+
+func TestValidate_DuplicateSupergiant(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Supergiants = []Supergiant{{Domain: "mastodon.social"}, {Domain: "mastodon.social"}}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("Expected duplicate supergiant problem, got %v", err)
+	}
+}
+
+func TestValidate_SupergiantSoftwareOwnsOneArm(t *testing.T) {
+	cfg := DefaultConfig.clone()
+	cfg.Supergiants = append(cfg.Supergiants, Supergiant{Domain: "mastodon.online", Software: "Mastodon"})
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "supergiants[3].software") {
+		t.Errorf("Expected a shared-arm problem, got %v", err)
+	}
+}
@@ -560,6 +560,14 @@ function onFediverseClick(event) {
   showInstanceDetails(data);
 }
 
+// escapeHTML returns text with HTML special characters escaped, for data
+// values built into innerHTML strings
+function escapeHTML(text) {
+  var div = document.createElement("div");
+  div.textContent = text;
+  return div.innerHTML;
+}
+
 function showInstanceDetails(data) {
   var starNameEl = $("#star-name");
   if (starNameEl) hide(starNameEl);
@@ -655,6 +663,27 @@ function showInstanceDetails(data) {
       "</p>";
   }
 
+  // Unknown-software dust clustered by domain (see dust.nebula_key)
+  if (data.nebula) {
+    html += "<p><strong>Nebula:</strong> " + escapeHTML(data.nebula) + "</p>";
+  }
+
+  // Merged datasets record which source supplied each field
+  if (data.provenance) {
+    var sources = [];
+    for (var field in data.provenance) {
+      if (sources.indexOf(data.provenance[field]) === -1) {
+        sources.push(data.provenance[field]);
+      }
+    }
+    if (sources.length > 0) {
+      html +=
+        "<p><strong>Observed By:</strong> " +
+        sources.sort().map(escapeHTML).join(" · ") +
+        "</p>";
+    }
+  }
+
   html += "</div>";
 
   if (bodyEl) bodyEl.innerHTML = html;
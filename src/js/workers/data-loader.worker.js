@@ -12,6 +12,11 @@ self.onmessage = function (e) {
      return;
   }
 
+  if (/\.bin($|\?)/.test(dataFile)) {
+    loadBinary(dataFile, SCALE_FACTOR, startedAt);
+    return;
+  }
+
   fetch(dataFile)
     .then((response) => {
       if (!response.ok) {
@@ -85,3 +90,145 @@ self.onmessage = function (e) {
       });
     });
 };
+
+// Binary layout written by `fediverse-processor -output <file>.bin`.
+// See scripts/fediverse-processor/binary.go for the full description.
+const BINARY_MAGIC = "FEDI";
+const BINARY_VERSION = 1;
+const BINARY_HEADER_SIZE = 16;
+const BINARY_SECTION_SIZE = 16;
+
+function fetchOk(url, kind) {
+  return fetch(url).then((response) => {
+    if (!response.ok) {
+      throw new Error(`HTTP error! status: ${response.status} (${url})`);
+    }
+    return kind === "json" ? response.json() : response.arrayBuffer();
+  });
+}
+
+function parseBinary(buffer) {
+  const view = new DataView(buffer);
+  let magic = "";
+  for (let i = 0; i < 4; i++) magic += String.fromCharCode(view.getUint8(i));
+  if (magic !== BINARY_MAGIC) {
+    throw new Error("Data format error: not a fediverse binary file");
+  }
+  const version = view.getUint16(4, true);
+  if (version !== BINARY_VERSION) {
+    throw new Error("Data format error: unsupported binary version " + version);
+  }
+
+  const sectionCount = view.getUint16(6, true);
+  const count = view.getUint32(8, true);
+  const scale = view.getFloat32(12, true);
+  const sections = {};
+  for (let i = 0; i < sectionCount; i++) {
+    const base = BINARY_HEADER_SIZE + i * BINARY_SECTION_SIZE;
+    let name = "";
+    for (let j = 0; j < 8; j++) {
+      const c = view.getUint8(base + j);
+      if (c !== 0) name += String.fromCharCode(c);
+    }
+    sections[name] = {
+      offset: view.getUint32(base + 8, true),
+      length: view.getUint32(base + 12, true),
+    };
+  }
+
+  const typed = (name, Type) => {
+    const s = sections[name];
+    if (!s) throw new Error("Data format error: missing section " + name);
+    return new Type(buffer, s.offset, s.length / Type.BYTES_PER_ELEMENT);
+  };
+
+  return {
+    count: count,
+    scale: scale,
+    positions: typed("position", Float32Array),
+    users: typed("users", Uint32Array),
+    hues: typed("hue", Float32Array),
+    software: typed("software", Uint16Array),
+    colors: typed("color", Uint8Array),
+    types: typed("type", Uint8Array),
+  };
+}
+
+function loadBinary(dataFile, SCALE_FACTOR, startedAt) {
+  const metaFile = dataFile.replace(/\.bin($|\?)/, ".meta.json$1");
+
+  Promise.all([fetchOk(dataFile, "binary"), fetchOk(metaFile, "json")])
+    .then(([buffer, meta]) => {
+      const bin = parseBinary(buffer);
+      if (meta.count !== bin.count) {
+        throw new Error("Data format error: side file does not match binary (" + meta.count + " vs " + bin.count + ")");
+      }
+
+      // Positions are stored pre-divided; only rescale if the page uses another scale
+      const positions = bin.positions;
+      if (bin.scale !== SCALE_FACTOR) {
+        const k = bin.scale / SCALE_FACTOR;
+        for (let i = 0; i < positions.length; i++) positions[i] *= k;
+      }
+
+      // Lightweight records for picking and the info panel
+      const data = new Array(bin.count);
+      for (let i = 0; i < bin.count; i++) {
+        const m = meta.instances[i];
+        data[i] = {
+          domain: m.domain,
+          name: m.name,
+          description: m.description,
+          first_seen_at: m.first_seen_at,
+          nebula: m.nebula,
+          provenance: m.provenance,
+          software: { name: meta.software[bin.software[i]] },
+          stats: { user_count: bin.users[i] },
+          positionType: meta.types[bin.types[i]],
+          position: {
+            x: positions[i * 3],
+            y: positions[i * 3 + 1],
+            z: positions[i * 3 + 2],
+          },
+          color: {
+            hsl: { h: bin.hues[i] },
+            rgb: { r: bin.colors[i * 3], g: bin.colors[i * 3 + 1], b: bin.colors[i * 3 + 2] },
+            temperature: m.temperature,
+            starType: m.star_type,
+          },
+        };
+      }
+
+      self.postMessage(
+        {
+          status: "success",
+          data: data,
+          buffers: {
+            positions: positions,
+            colors: bin.colors,
+            users: bin.users,
+            types: bin.types,
+          },
+          meta: {
+            count: bin.count,
+            valid: bin.count,
+            invalid: 0,
+            errors: [],
+            format: "binary",
+            ms: Date.now() - startedAt,
+          },
+        },
+        [buffer],
+      );
+    })
+    .catch((error) => {
+      self.postMessage({
+        status: "error",
+        error: error.message,
+        meta: {
+          url: dataFile,
+          ms: Date.now() - startedAt,
+        },
+      });
+    });
+}